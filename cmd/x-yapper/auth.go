@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/credentials"
+	"x-dev/internal/models"
+	"x-dev/internal/prompt"
+	"x-dev/internal/xauth"
+)
+
+type session struct {
+	token         *models.TokenResponse
+	user          models.UserResponse
+	maxPostLength int
+}
+
+// authenticate signs in through the browser and stores the resulting token
+// under profile for commands that run unattended.
+func authenticate(ctx context.Context, profile string) (*session, error) {
+	fmt.Println(prompt.Info("[INFO] "), "getting environment variables")
+
+	clientID, clientSecret, err := config.LoadClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	fmt.Println(prompt.Success("[OK] "), "environment variables set")
+	fmt.Println(prompt.Success("[OK] "), "starting authentication service")
+
+	codeVerifier := xauth.GenerateCodeVerifier()
+	codeChallenge := xauth.GenerateCodeChallenge(codeVerifier)
+	authState := xauth.GenerateRandomString(32)
+
+	fmt.Println(prompt.Success("[OK] "), "starting callback server")
+
+	serverCtx, cancelServer := context.WithCancel(ctx)
+
+	var wGroup sync.WaitGroup
+
+	defer func() {
+		cancelServer()
+		wGroup.Wait()
+	}()
+
+	api.StartCallbackServer(serverCtx, &wGroup, authState)
+
+	fmt.Println(prompt.Success("[OK] "), "creating unique authentication URL")
+
+	u, err := url.Parse(models.AuthEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse auth endpoint: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", clientID)
+	q.Set("redirect_uri", fmt.Sprintf("http://localhost:%s%s", models.CallbackPort, models.CallbackEndpoint))
+	q.Set("scope", models.Scopes)
+	q.Set("state", authState)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+
+	u.RawQuery = q.Encode()
+	authURL := u.String()
+
+	fmt.Printf("\nOpen this URL in your browser to authorize the application:\n\n%s\n\n", authURL)
+
+	var code string
+
+	select {
+	case code = <-models.AuthTokenChan:
+	case <-ctx.Done():
+		fmt.Println(prompt.Warn("[WARN] "), "received interrupt, shutting down...")
+		return nil, ctx.Err()
+	}
+
+	tokenResponse, err := xauth.ExchangeCodeForToken(ctx, clientID, clientSecret, codeVerifier, code)
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging code for token: %w", err)
+	}
+
+	fmt.Println(prompt.Success("[OK] "), "authentication successful")
+
+	maxPostLength, userResponse, err := api.CheckAccountType(ctx, tokenResponse.AccessToken)
+	if err != nil {
+		maxPostLength = api.MaxPostLength(false)
+
+		fmt.Printf("could not determine tweet length limit: %v", err)
+		fmt.Println(prompt.Info("[INFO] "), "standard post length requirements set")
+	}
+
+	if err := credentials.Save(profile, tokenResponse, userResponse); err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), "could not store login:", err)
+	}
+
+	return &session{
+		token:         tokenResponse,
+		user:          userResponse,
+		maxPostLength: maxPostLength,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"x-dev/internal/api"
+	"x-dev/internal/communities"
+	"x-dev/internal/config"
+	"x-dev/internal/models"
+	"x-dev/internal/postref"
+	"x-dev/internal/prompt"
+)
+
+func runCommunitiesCommand(ctx context.Context, args []string) error {
+	if len(args) > 0 && args[0] == "search" {
+		return communitiesSearch(ctx, args[1:])
+	}
+
+	flags := flag.NewFlagSet("communities", flag.ContinueOnError)
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	if len(profile.Communities) == 0 {
+		fmt.Println(prompt.Info("[INFO] "), fmt.Sprintf("profile %q lists no communities, add their IDs under \"communities\" in config.json", profile.Name))
+		fmt.Println(prompt.Info("[INFO] "), "find them with \"x-yapper communities search <name>\"")
+		return nil
+	}
+
+	sess, err := authenticate(ctx, profile.Name)
+	if err != nil {
+		return err
+	}
+
+	joined, err := communities.Joined(ctx, profile.Communities, sess.token.AccessToken)
+	if err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), "some communities could not be looked up:", err)
+	}
+
+	printCommunities(joined)
+
+	return nil
+}
+
+func communitiesSearch(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("communities search", flag.ContinueOnError)
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
+	limitFlag := flags.Int("limit", 10, "show at most this many communities, up to 100")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return errors.New("usage: x-yapper communities search [--limit N] <name>")
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	sess, err := authenticate(ctx, profile.Name)
+	if err != nil {
+		return err
+	}
+
+	found, _, err := api.SearchCommunities(ctx, flags.Arg(0), *limitFlag, sess.token.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	if len(found) == 0 {
+		fmt.Println(prompt.Info("[INFO] "), "no communities found")
+		return nil
+	}
+
+	if *limitFlag > 0 && len(found) > *limitFlag {
+		found = found[:*limitFlag]
+	}
+
+	printCommunities(found)
+
+	return nil
+}
+
+func printCommunities(list []models.Community) {
+	for _, community := range list {
+		fmt.Println("------------------------------------------------------------")
+		fmt.Println(communities.Label(community))
+		fmt.Println(postref.CommunityLink(community.ID))
+
+		if community.MemberCount > 0 || community.Access != "" {
+			fmt.Printf("%d members, %s, join policy %s\n", community.MemberCount, community.Access, community.JoinPolicy)
+		}
+
+		if community.Description != "" {
+			fmt.Println(summarize(community.Description, 70))
+		}
+	}
+
+	fmt.Println("------------------------------------------------------------")
+}
+
+// communityLabel names a community recorded by ID, using the details seen
+// the last time it was looked up.
+func communityLabel(communityID string) string {
+	community, ok := communities.Cached(communityID)
+	if !ok {
+		return communityID
+	}
+
+	return communities.Label(community)
+}
+
+// joinedCommunities looks up the profile's communities for the composer. A
+// failed lookup only warns; the communities are still offered by ID.
+func joinedCommunities(ctx context.Context, profile *config.ProfileSettings, accessToken string) []models.Community {
+	joined, err := communities.Joined(ctx, profile.Communities, accessToken)
+	if err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), "some communities could not be looked up:", err)
+	}
+
+	return joined
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/credentials"
+	"x-dev/internal/crosspost"
+	"x-dev/internal/media"
+	"x-dev/internal/models"
+	"x-dev/internal/outbox"
+	"x-dev/internal/prompt"
+	"x-dev/internal/templates"
+)
+
+// crossPoster sends posts from the configured profiles with their stored
+// logins. It returns nil when current is the only profile.
+func crossPoster(settings *config.Settings, current string, uploadOpts media.UploadOptions) *crosspost.Poster {
+	poster := &crosspost.Poster{Settings: settings, Publish: publishFor(settings, uploadOpts), MaxPostLength: storedMaxPostLength}
+
+	others := slices.DeleteFunc(poster.Profiles(), func(name string) bool { return name == current })
+	if len(others) == 0 {
+		return nil
+	}
+
+	return poster
+}
+
+// storedMaxPostLength is the length limit of the account profile last
+// signed in as, or the standard one when it has no stored login yet.
+func storedMaxPostLength(profile string) int {
+	stored, err := credentials.Load(profile)
+	if err != nil {
+		return api.MaxPostLength(false)
+	}
+
+	return api.MaxPostLength(stored.Verified)
+}
+
+// publishFor returns the send function of a cross-post. Each profile
+// uploads its own copy of the media; a post that cannot reach X waits in
+// the outbox like any other.
+func publishFor(settings *config.Settings, uploadOpts media.UploadOptions) crosspost.SendFunc {
+	return func(ctx context.Context, target crosspost.Target) crosspost.Result {
+		result := crosspost.Result{Profile: target.Profile}
+
+		clientID, clientSecret, err := config.LoadClientConfig()
+		if err != nil {
+			result.Err = fmt.Errorf("failed to load configuration: %w", err)
+			return result
+		}
+
+		stored, err := credentials.Fresh(ctx, target.Profile, clientID, clientSecret)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		result.Username = stored.Username
+
+		out := target.Post
+
+		if result.Err = checkLength(&out, api.MaxPostLength(stored.Verified)); result.Err != nil {
+			return result
+		}
+
+		opts := uploadOpts
+		opts.Account = target.Profile
+		opts.Progress = nil
+
+		postResponse, rateLimit, err := publish(ctx, &out, stored.AccessToken, stored.Verified, settings.Accessibility.AltTextPolicy, opts)
+		if api.IsUnavailable(err) {
+			item := &models.OutboxItem{Profile: target.Profile, OutgoingPost: out}
+			if addErr := outbox.Add(item); addErr != nil {
+				result.Err = fmt.Errorf("X is unreachable (%v) and the post could not be queued: %w", err, addErr)
+				return result
+			}
+
+			result.OutboxID = item.ID
+
+			return result
+		}
+
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		recordSent(target.Profile, stored.Username, &out, postResponse.Data.ID, rateLimit)
+		result.PostID = postResponse.Data.ID
+
+		return result
+	}
+}
+
+// crossPost is "post --profiles": it renders the post for every profile and
+// checks each version before any of them is sent, so an announcement does
+// not go out from only some of the accounts because of a typo.
+func crossPost(ctx context.Context, settings *config.Settings, uploadOpts media.UploadOptions, pf *postFlags, templateName string, vars []string, profiles []string) error {
+	values, err := parseVars(vars)
+	if err != nil {
+		return err
+	}
+
+	if templateName != "" {
+		if *pf.text, err = templates.Load(templateName); err != nil {
+			return err
+		}
+	}
+
+	// Link rules and reply settings come from each profile in Prepare.
+	out, err := pf.outgoing(&config.ProfileSettings{})
+	if err != nil {
+		return err
+	}
+
+	for i := range profiles {
+		profiles[i] = strings.TrimSpace(profiles[i])
+	}
+
+	poster := &crosspost.Poster{Settings: settings, Publish: publishFor(settings, uploadOpts), MaxPostLength: storedMaxPostLength}
+
+	targets, err := poster.Prepare(profiles, *out, values)
+	if err != nil {
+		return err
+	}
+
+	for i := range targets {
+		target := &targets[i]
+
+		profile, err := settings.Profile(target.Profile)
+		if err != nil {
+			return err
+		}
+
+		stored, err := storedLogin(ctx, profile.Name)
+		if err != nil {
+			return fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+
+		for _, change := range target.Links {
+			fmt.Println(prompt.Info("[INFO] "), profile.Name+": link rewritten:", change.From, "->", change.To)
+		}
+
+		maxPostLength := api.MaxPostLength(stored.Verified)
+
+		if err := checkLength(&target.Post, maxPostLength); err != nil {
+			return fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+
+		findings, err := poster.Lint(*target, nil)
+		if err != nil {
+			return err
+		}
+
+		if prompt.PrintFindings(findings) {
+			return fmt.Errorf("nothing sent, the post for profile %q has lint errors", profile.Name)
+		}
+
+		if err := checkSecrets(&target.Post, profile.Name, "post", *pf.allowSecrets, stored.AccessToken); err != nil {
+			return fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+	}
+
+	results := poster.Send(ctx, targets)
+	prompt.PrintCrossPostSummary(results)
+
+	if failed := crosspost.Failed(results); failed > 0 {
+		return fmt.Errorf("the post did not reach %d of %d profiles", failed, len(results))
+	}
+
+	return nil
+}
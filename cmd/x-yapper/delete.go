@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/models"
+	"x-dev/internal/postref"
+	"x-dev/internal/prompt"
+)
+
+func runDeleteCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("delete", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "delete without asking for confirmation")
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: x-yapper delete [flags] <post ID or x.com status URL>")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("delete takes exactly one post ID or URL")
+	}
+
+	postID, err := postref.ParseID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	sess, err := authenticate(ctx, profile.Name)
+	if err != nil {
+		return err
+	}
+
+	postResponse, _, err := api.GetPost(ctx, postID, sess.token.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	if postResponse.Data.AuthorID != sess.user.Data.ID {
+		return fmt.Errorf("post %s belongs to another account, you can only delete your own posts", postID)
+	}
+
+	fmt.Printf("\n%s\n\n", postResponse.Data.Text)
+
+	if !*yes {
+		confirmed, err := prompt.ConfirmDelete(postID)
+		if err != nil {
+			return err
+		}
+
+		if !confirmed {
+			fmt.Println(prompt.Info("[INFO] "), "post kept")
+			return nil
+		}
+	}
+
+	rateLimit, err := api.DeletePost(ctx, postID, sess.token.AccessToken)
+	if err != nil {
+		return err
+	}
+
+	recordHistory(models.HistoryRecord{
+		ID:        postID,
+		Action:    models.HistoryActionDelete,
+		Profile:   profile.Name,
+		Username:  sess.user.Data.Username,
+		Text:      postResponse.Data.Text,
+		RateLimit: rateLimit,
+	})
+
+	fmt.Println(prompt.Success("[OK] "), "post deleted, post ID:", postID)
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"x-dev/internal/history"
+	"x-dev/internal/models"
+	"x-dev/internal/postref"
+	"x-dev/internal/prompt"
+)
+
+const historyTimeFormat = "2006-01-02 15:04"
+
+func runHistoryCommand(args []string) error {
+	if len(args) > 0 && args[0] == "show" {
+		if len(args) != 2 {
+			return errors.New("usage: x-yapper history show <post ID or x.com status URL>")
+		}
+
+		return historyShow(args[1])
+	}
+
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	profileFlag := flags.String("profile", "", "only show posts of this profile")
+	actionFlag := flags.String("action", "", "only show one kind of entry: post, reply, quote or delete")
+	sinceFlag := flags.String("since", "", "only show entries newer than this, e.g. 12h, 7d or 2025-03-10")
+	searchFlag := flags.String("search", "", "only show posts whose text contains this, ignoring case")
+	limitFlag := flags.Int("limit", 20, "show at most this many entries, 0 for all")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	filter := history.Filter{
+		Profile: *profileFlag,
+		Action:  *actionFlag,
+		Search:  *searchFlag,
+	}
+
+	switch filter.Action {
+	case "", models.HistoryActionPost, models.HistoryActionReply, models.HistoryActionQuote, models.HistoryActionDelete:
+	default:
+		return fmt.Errorf("unknown --action %q, use post, reply, quote or delete", filter.Action)
+	}
+
+	if *sinceFlag != "" {
+		since, err := sinceTime(*sinceFlag, time.Now())
+		if err != nil {
+			return err
+		}
+
+		filter.Since = since
+	}
+
+	records, err := history.Load()
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+
+	for i := len(records) - 1; i >= 0; i-- {
+		if *limitFlag > 0 && shown == *limitFlag {
+			break
+		}
+
+		rec := records[i]
+		if !filter.Match(rec) {
+			continue
+		}
+
+		shown++
+
+		fmt.Printf("%s  %-6s  %-10s %s\n", rec.Time.Local().Format(historyTimeFormat), rec.Action, rec.Profile,
+			postref.Permalink(rec.Username, rec.ID))
+
+		if rec.Text != "" {
+			fmt.Println("     ", summarize(rec.Text, 70))
+		}
+	}
+
+	if shown == 0 {
+		fmt.Println(prompt.Info("[INFO] "), "no matching posts in the history")
+	}
+
+	return nil
+}
+
+func historyShow(input string) error {
+	postID, err := postref.ParseID(input)
+	if err != nil {
+		return err
+	}
+
+	records, err := history.Load()
+	if err != nil {
+		return err
+	}
+
+	rec, ok := history.Find(records, postID)
+	if !ok {
+		return fmt.Errorf("post %s is not in the history", postID)
+	}
+
+	fmt.Printf("Post ID:     %s\n", rec.ID)
+	fmt.Printf("Link:        %s\n", postref.Permalink(rec.Username, rec.ID))
+	fmt.Printf("Profile:     %s\n", rec.Profile)
+	fmt.Printf("Kind:        %s\n", rec.Action)
+	fmt.Printf("Sent:        %s\n", rec.Time.Local().Format("Mon Jan 2 15:04 MST 2006"))
+
+	if rec.ReplyTo != "" {
+		fmt.Printf("Reply to:    %s\n", postref.Permalink("", rec.ReplyTo))
+	}
+
+	if rec.QuoteID != "" {
+		fmt.Printf("Quotes:      %s\n", postref.Permalink("", rec.QuoteID))
+	}
+
+	if rec.ReplySettings != "" {
+		fmt.Printf("Replies:     %s\n", rec.ReplySettings)
+	}
+
+	if rec.CommunityID != "" {
+		fmt.Printf("Community:   %s\n", communityLabel(rec.CommunityID))
+	}
+
+	if rec.RateLimit != nil {
+		fmt.Printf("Rate limit:  %d of %d left, resets %s\n", rec.RateLimit.Remaining, rec.RateLimit.Limit,
+			rec.RateLimit.ResetTime.Local().Format("Jan 2 15:04"))
+	}
+
+	for _, other := range records {
+		if other.ID == rec.ID && other.Action == models.HistoryActionDelete {
+			fmt.Printf("Deleted:     %s\n", other.Time.Local().Format("Mon Jan 2 15:04 MST 2006"))
+		}
+	}
+
+	fmt.Println("------------------------------------------------------------")
+	fmt.Println(rec.Text)
+	fmt.Println("------------------------------------------------------------")
+
+	return nil
+}
+
+// sinceTime reads a --since value: a number of days such as 7d, a Go
+// duration, or a date.
+func sinceTime(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return now.Add(-duration), nil
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid --since %q, use e.g. 12h, 7d or 2025-03-10", value)
+}
+
+// recordSent keeps a post sent by a command in the history. A failure only
+// warns, since the post itself went through.
+func recordSent(profile string, username string, out *models.OutgoingPost, postID string, rateLimit *models.RateLimitInfo) {
+	recordHistory(models.HistoryRecord{
+		ID:            postID,
+		Action:        history.ActionFor(out.ReplyTo, out.QuoteID),
+		Profile:       profile,
+		Username:      username,
+		Text:          out.Text,
+		ReplyTo:       out.ReplyTo,
+		QuoteID:       out.QuoteID,
+		ReplySettings: out.ReplySettings,
+		CommunityID:   out.CommunityID,
+		RateLimit:     rateLimit,
+	})
+}
+
+func recordHistory(rec models.HistoryRecord) {
+	if err := history.Record(rec); err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), err)
+	}
+}
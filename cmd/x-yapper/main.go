@@ -2,100 +2,227 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"net/url"
 	"os"
 	"os/signal"
-	"sync"
+	"strings"
 	"syscall"
 
-	"x-dev/internal/api"
 	"x-dev/internal/config"
+	"x-dev/internal/links"
+	"x-dev/internal/lint"
+	"x-dev/internal/media"
 	"x-dev/internal/models"
 	"x-dev/internal/prompt"
-	"x-dev/internal/xauth"
+	"x-dev/internal/spelling"
 )
 
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	args := os.Args[1:]
 
-	fmt.Println(prompt.Info("[INFO] "), "getting environment variables")
+	var err error
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		err = runCommand(ctx, args[0], args[1:])
+	} else {
+		err = runInteractive(ctx, args)
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, flag.ErrHelp) {
+		return
+	}
 
-	clientID, clientSecret, err := config.LoadClientConfig()
 	if err != nil {
-		fmt.Println(prompt.Failed("[ERROR]"), "failed to load configuration:", err)
+		fmt.Println(prompt.Failed("[ERROR]"), err)
 
-		cancel()
+		stop()
 		os.Exit(1)
 	}
+}
+
+func runCommand(ctx context.Context, name string, args []string) error {
+	switch name {
+	case "post":
+		return runPostCommand(ctx, args)
+	case "delete":
+		return runDeleteCommand(ctx, args)
+	case "schedule":
+		return runScheduleCommand(ctx, args)
+	case "scheduler":
+		return runSchedulerCommand(ctx, args)
+	case "template":
+		return runTemplateCommand(ctx, args)
+	case "outbox":
+		return runOutboxCommand(ctx, args)
+	case "history":
+		return runHistoryCommand(args)
+	case "communities":
+		return runCommunitiesCommand(ctx, args)
+	case "mastodon":
+		return runMastodonCommand(ctx, args)
+	case "complete-mention":
+		return runCompleteMentionCommand(args)
+	case "help":
+		printUsage()
+		return nil
+	default:
+		printUsage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func printUsage() {
+	fmt.Println(`Usage:
+  x-yapper [flags]          start the interactive prompt
+  x-yapper post [flags]     send a post without the interactive prompt
+  x-yapper delete <id|url>  delete one of your posts
+  x-yapper schedule [flags] queue a post, or list, show, edit and remove queued posts
+  x-yapper scheduler run    send queued posts when they are due
+  x-yapper template <cmd>   list, show or edit post templates
+  x-yapper outbox <cmd>     list, flush or remove posts that could not reach X
+  x-yapper history [flags]  list and search what you posted and deleted
+  x-yapper communities [search <name>]
+                            list your profile's X Communities, or search for one
+  x-yapper mastodon [login] show the profile's Mastodon account, or sign in to one
+  x-yapper complete-mention [prefix]
+                            print known @handles or #hashtags starting with prefix
+  x-yapper help             show this message
+
+Run "x-yapper <command> -h" for the flags of a command.`)
+}
+
+func runInteractive(ctx context.Context, args []string) error {
+	var mediaPaths stringList
 
-	fmt.Println(prompt.Success("[OK] "), "environment variables set")
-	fmt.Println(prompt.Success("[OK] "), "starting authentication service")
+	flags := flag.NewFlagSet("x-yapper", flag.ContinueOnError)
+	flags.Var(&mediaPaths, "media", "attach a media file to the first new post (repeatable, up to 4)")
+	chunkSizeFlag := flags.String("chunk-size", "", "segment size for chunked video and GIF uploads, e.g. 4MiB")
+	uploadWorkersFlag := flags.Int("upload-workers", 0, "number of parallel segment uploads for videos")
+	undoFlag := flags.Int("undo", -1, "seconds to offer undo after sending a new post, 0 to turn it off")
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
 
-	codeVerifier := xauth.GenerateCodeVerifier()
-	codeChallenge := xauth.GenerateCodeChallenge(codeVerifier)
-	authState := xauth.GenerateRandomString(32)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if len(mediaPaths) > models.MaxMediaAttachments {
+		return fmt.Errorf("too many --media files, maximum is %d", models.MaxMediaAttachments)
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
 
-	fmt.Println(prompt.Success("[OK] "), "starting callback server")
+	uploadOpts, err := uploadOptions(settings, *chunkSizeFlag, *uploadWorkersFlag)
+	if err != nil {
+		return err
+	}
 
-	var wGroup sync.WaitGroup
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
 
-	api.StartCallbackServer(ctx, &wGroup, authState)
+	if *undoFlag >= 0 {
+		if err := config.ValidateUndoSeconds(*undoFlag); err != nil {
+			return err
+		}
 
-	fmt.Println(prompt.Success("[OK] "), "creating unique authentication URL")
+		settings.Compose.UndoSeconds = *undoFlag
+	}
 
-	u, err := url.Parse(models.AuthEndpoint)
+	linter, err := lint.New(profile.Lint)
 	if err != nil {
-		log.Fatalf("failed to parse auth endpoint: %v", err)
+		return fmt.Errorf("profile %q: %w", profile.Name, err)
 	}
 
-	q := u.Query()
-	q.Set("response_type", "code")
-	q.Set("client_id", clientID)
-	q.Set("redirect_uri", fmt.Sprintf("http://localhost:%s%s", models.CallbackPort, models.CallbackEndpoint))
-	q.Set("scope", models.Scopes)
-	q.Set("state", authState)
-	q.Set("code_challenge", codeChallenge)
-	q.Set("code_challenge_method", "S256")
+	sess, err := authenticate(ctx, profile.Name)
+	if err != nil {
+		return err
+	}
 
-	u.RawQuery = q.Encode()
-	authURL := u.String()
+	flushOnStart(ctx, settings, uploadOpts)
 
-	fmt.Printf("\nOpen this URL in your browser to authorize the application:\n\n%s\n\n", authURL)
+	fmt.Println(prompt.Success("[OK] "), "starting x-yapper prompt")
 
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	if profile.Name != config.DefaultProfileName {
+		fmt.Println(prompt.Info("[INFO] "), "using profile", profile.Name)
+	}
 
-	select {
-	case code := <-models.AuthTokenChan:
-		tokenResponse, err := xauth.ExchangeCodeForToken(ctx, clientID, clientSecret, codeVerifier, code)
-		if err != nil {
-			log.Fatalf("error exchanging code for token: %v", err)
-		}
+	if err := prompt.RunPrompts(ctx, sess.token, sess.maxPostLength, sess.user, prompt.Options{
+		MediaPaths:      mediaPaths,
+		Upload:          uploadOpts,
+		AltTextPolicy:   settings.Accessibility.AltTextPolicy,
+		ThreadSeparator: settings.Compose.ThreadSeparator,
+		UndoSeconds:     settings.Compose.UndoSeconds,
+		ReplySettings:   profile.ReplySettings,
+		Profile:         profile.Name,
+		Username:        sess.user.Data.Username,
+		Duplicates:      settings.Duplicates,
+		Lint:            linter,
+		KnownSecrets:    knownSecrets(sess.token.AccessToken),
+		Spelling:        spellChecker(settings, profile),
+		Links:           links.New(profile.Links),
+		Communities:     joinedCommunities(ctx, profile, sess.token.AccessToken),
+		CrossPost:       crossPoster(settings, profile.Name, uploadOpts),
+		Mastodon:        mastodonTarget(ctx, profile),
+	}); err != nil {
+		return fmt.Errorf("error: %w", err)
+	}
 
-		fmt.Println(prompt.Success("[OK] "), "authentication successful, starting x-yapper prompt")
+	return nil
+}
 
-		var maxPostLength int
+// spellChecker loads the dictionary for profile. A missing or broken
+// dictionary only turns the check off, it does not keep anyone from posting.
+func spellChecker(settings *config.Settings, profile *config.ProfileSettings) *spelling.Checker {
+	language := settings.SpellingLanguage(profile)
+	if language == "" {
+		return nil
+	}
 
-		maxPostLength, userResponse, err := api.CheckAccountType(ctx, tokenResponse.AccessToken)
-		if err != nil {
-			maxPostLength = 280
+	checker, err := spelling.New(language, settings.Spelling.DictionaryPaths, profile.Spelling.Words, profile.Spelling.WordsFile)
+	if err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), "spell check is off:", err)
+		return nil
+	}
 
-			fmt.Printf("could not determine tweet length limit: %v", err)
-			fmt.Println(prompt.Info("[INFO] "), "standard post length requirements set")
-		}
+	return checker
+}
 
-		if err := prompt.RunPrompts(ctx, tokenResponse, maxPostLength, userResponse); err != nil {
-			log.Fatalf("error: %v", err)
-		}
+func uploadOptions(settings *config.Settings, chunkSize string, workers int) (media.UploadOptions, error) {
+	if chunkSize != "" {
+		settings.Media.ChunkSize = chunkSize
+	}
 
-	case <-sigChan:
-		fmt.Println(prompt.Warn("[WARN] "), "received interrupt, shutting down...")
+	if workers > 0 {
+		settings.Media.UploadWorkers = workers
+	}
 
-		cancel()
+	chunkBytes, err := config.ParseChunkSize(settings.Media.ChunkSize)
+	if err != nil {
+		return media.UploadOptions{}, err
 	}
 
-	wGroup.Wait()
+	return media.UploadOptions{
+		ChunkSize: chunkBytes,
+		Workers:   settings.Media.UploadWorkers,
+	}, nil
 }
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sync"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/mastodon"
+	"x-dev/internal/models"
+	"x-dev/internal/prompt"
+	"x-dev/internal/xauth"
+)
+
+func runMastodonCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "login" {
+		return mastodonStatus(ctx, args)
+	}
+
+	flags := flag.NewFlagSet("mastodon login", flag.ContinueOnError)
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
+	instanceFlag := flags.String("instance", "", "Mastodon instance to sign in on, defaults to the profile's mastodon.instance")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	instance := *instanceFlag
+	if instance == "" {
+		instance = profile.Mastodon.Instance
+	}
+
+	if instance == "" {
+		return errors.New("no mastodon instance, pass --instance or set mastodon.instance for the profile in config.json")
+	}
+
+	instance, err = mastodon.NormalizeInstance(instance)
+	if err != nil {
+		return err
+	}
+
+	login, err := mastodonLogin(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	if err := mastodon.SaveLogin(profile.Name, login); err != nil {
+		return err
+	}
+
+	target := &mastodon.Target{Login: login, Limits: instanceLimits(ctx, instance)}
+
+	fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("profile %q posts to Mastodon as %s", profile.Name, target.Label()))
+	fmt.Println(prompt.Info("[INFO] "), fmt.Sprintf("%s allows %d characters per post", instance, target.Limits.MaxCharacters))
+
+	if profile.Mastodon.Instance == "" {
+		fmt.Println(prompt.Info("[INFO] "), "set mastodon.instance for the profile in config.json to offer Mastodon in the composer")
+	}
+
+	return nil
+}
+
+// mastodonLogin signs in on instance through the browser, registering
+// x-yapper there first if no profile has signed in on it before.
+func mastodonLogin(ctx context.Context, instance string) (*models.MastodonLogin, error) {
+	redirectURI := fmt.Sprintf("http://localhost:%s%s", models.CallbackPort, models.CallbackEndpoint)
+
+	app, registered, err := mastodon.App(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	if !registered {
+		fmt.Println(prompt.Info("[INFO] "), "registering x-yapper on", instance)
+
+		if app, err = mastodon.RegisterApp(ctx, instance, redirectURI); err != nil {
+			return nil, err
+		}
+
+		if err := mastodon.SaveApp(instance, app); err != nil {
+			return nil, err
+		}
+	}
+
+	authState := xauth.GenerateRandomString(32)
+
+	serverCtx, cancelServer := context.WithCancel(ctx)
+
+	var wGroup sync.WaitGroup
+
+	defer func() {
+		cancelServer()
+		wGroup.Wait()
+	}()
+
+	api.StartCallbackServer(serverCtx, &wGroup, authState)
+
+	fmt.Printf("\nOpen this URL in your browser to authorize the application:\n\n%s\n\n", mastodon.AuthorizeURL(instance, app, redirectURI, authState))
+
+	var code string
+
+	select {
+	case code = <-models.AuthTokenChan:
+	case <-ctx.Done():
+		fmt.Println(prompt.Warn("[WARN] "), "received interrupt, shutting down...")
+		return nil, ctx.Err()
+	}
+
+	token, err := mastodon.ExchangeCode(ctx, instance, app, redirectURI, code)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := mastodon.VerifyCredentials(ctx, instance, token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MastodonLogin{
+		Instance:    instance,
+		AccountID:   account.ID,
+		Username:    account.Username,
+		AccessToken: token.AccessToken,
+	}, nil
+}
+
+func mastodonStatus(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("mastodon", flag.ContinueOnError)
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unknown mastodon command %q, use login", flags.Arg(0))
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	login, err := mastodon.Login(profile.Name)
+	if err != nil {
+		return err
+	}
+
+	target := &mastodon.Target{Login: login, Limits: instanceLimits(ctx, login.Instance)}
+
+	fmt.Printf("Account:     %s\n", target.Label())
+	fmt.Printf("Limit:       %d characters, links count as %d\n", target.Limits.MaxCharacters, target.Limits.ReservedPerURL)
+	fmt.Printf("Visibility:  %s\n", profile.Mastodon.Visibility)
+
+	if profile.Mastodon.ContentWarning != "" {
+		fmt.Printf("CW:          %s\n", profile.Mastodon.ContentWarning)
+	}
+
+	if profile.Mastodon.Enabled {
+		fmt.Println("New posts go to Mastodon too unless turned off in the preview.")
+	}
+
+	return nil
+}
+
+// instanceLimits reads the limits from the instance configuration, falling
+// back to Mastodon's defaults when the instance cannot be reached.
+func instanceLimits(ctx context.Context, instance string) mastodon.Limits {
+	info, err := mastodon.GetInstance(ctx, instance)
+	if err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), "using Mastodon's default limits:", err)
+		info = &models.MastodonInstance{}
+	}
+
+	return mastodon.LimitsOf(info)
+}
+
+// mastodonTarget returns the profile's Mastodon account for the composer,
+// or nil when the profile has none or has not signed in to it.
+func mastodonTarget(ctx context.Context, profile *config.ProfileSettings) *mastodon.Target {
+	if profile.Mastodon.Instance == "" {
+		return nil
+	}
+
+	login, err := mastodon.Login(profile.Name)
+	if err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), err)
+		return nil
+	}
+
+	return &mastodon.Target{
+		Login:   login,
+		Limits:  instanceLimits(ctx, login.Instance),
+		Enabled: profile.Mastodon.Enabled,
+		Defaults: mastodon.Options{
+			Visibility:     profile.Mastodon.Visibility,
+			ContentWarning: profile.Mastodon.ContentWarning,
+		},
+	}
+}
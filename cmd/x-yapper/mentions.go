@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"x-dev/internal/mentions"
+)
+
+// runCompleteMentionCommand prints the known handles, or hashtags, that
+// start with the given prefix, one per line, for editors to call while
+// typing. It never goes to the network, so it stays fast.
+func runCompleteMentionCommand(args []string) error {
+	flags := flag.NewFlagSet("complete-mention", flag.ContinueOnError)
+	limitFlag := flags.Int("limit", 20, "print at most this many completions, 0 for all")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		return errors.New("usage: x-yapper complete-mention [--limit N] [@prefix | #prefix]")
+	}
+
+	completions, err := mentions.Complete(flags.Arg(0), *limitFlag)
+	if err != nil {
+		return err
+	}
+
+	for _, completion := range completions {
+		fmt.Println(completion)
+	}
+
+	return nil
+}
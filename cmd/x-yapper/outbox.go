@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/credentials"
+	"x-dev/internal/media"
+	"x-dev/internal/models"
+	"x-dev/internal/outbox"
+	"x-dev/internal/prompt"
+	"x-dev/internal/store"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	outboxLockFile = "outbox.lock"
+
+	// sentLookback widens the search for posts that went through despite an
+	// error, since the request may have reached X a little before the item
+	// was queued.
+	sentLookback = 10 * time.Minute
+)
+
+func runOutboxCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: x-yapper outbox list | flush | remove ID")
+	}
+
+	switch args[0] {
+	case "list":
+		return outboxList()
+	case "flush":
+		settings, err := config.LoadSettings()
+		if err != nil {
+			return err
+		}
+
+		uploadOpts, err := uploadOptions(settings, "", 0)
+		if err != nil {
+			return err
+		}
+
+		uploadOpts.Progress = prompt.PrintUploadProgress
+
+		return flushOutbox(ctx, settings, uploadOpts)
+	case "remove":
+		if len(args) != 2 {
+			return errors.New("usage: x-yapper outbox remove ID")
+		}
+
+		removed, err := outbox.Remove(args[1])
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("removed %d item(s) from the outbox", removed))
+
+		return nil
+	default:
+		return fmt.Errorf("unknown outbox command %q, use list, flush or remove", args[0])
+	}
+}
+
+func outboxList() error {
+	items, err := outbox.List()
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+
+	for _, item := range items {
+		if item.PostID != "" {
+			continue
+		}
+
+		pending++
+
+		status := "queued"
+		if item.Failed {
+			status = "failed"
+		} else if item.RetryAt != nil && time.Now().Before(*item.RetryAt) {
+			status = "waiting"
+		}
+
+		after := ""
+		if item.ReplyToItem != "" {
+			after = "after " + item.ReplyToItem
+		}
+
+		fmt.Printf("%-4s %-8s %-10s %-14s %-10s %s\n", item.ID, status, item.Profile,
+			humanize.Time(item.QueuedAt), after, summarize(item.Text, 40))
+
+		if item.Error != "" {
+			fmt.Println("     ", prompt.Failed(item.Error))
+		}
+
+		if status == "waiting" {
+			fmt.Println("     ", "rate limited, retried", humanize.Time(*item.RetryAt))
+		}
+	}
+
+	if pending == 0 {
+		fmt.Println(prompt.Info("[INFO] "), "the outbox is empty")
+	}
+
+	return nil
+}
+
+// flushOutbox sends queued items in the order they were queued. Items that
+// turn out to be on the account already are only marked sent, and a queued
+// reply waits for its parent. Flushing stops at the first item X is still
+// unreachable for, so nothing is sent out of order. A rate-limited item
+// holds back the later items of its profile until the limit resets.
+func flushOutbox(ctx context.Context, settings *config.Settings, uploadOpts media.UploadOptions) error {
+	items, err := outbox.List()
+	if err != nil {
+		return err
+	}
+
+	if !hasPending(items) {
+		return nil
+	}
+
+	clientID, clientSecret, err := config.LoadClientConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	lock, err := store.AcquireLock(outboxLockFile, 10*time.Minute)
+	if err != nil {
+		return fmt.Errorf("the outbox is already being flushed: %w", err)
+	}
+
+	defer func() {
+		if err := lock.Release(); err != nil {
+			fmt.Println(prompt.Warn("[WARN] "), err)
+		}
+	}()
+
+	// Reload under the lock, another flush may have finished meanwhile.
+	if items, err = outbox.List(); err != nil {
+		return err
+	}
+
+	byID := make(map[string]*models.OutboxItem, len(items))
+	blocked := map[string]bool{}
+	waiting := map[string]bool{}
+	limited := map[string]bool{}
+	recent := map[string][]models.Tweet{}
+	used := map[string]bool{}
+	sent := 0
+
+	for _, item := range items {
+		byID[item.ID] = item
+
+		if item.PostID != "" {
+			continue
+		}
+
+		if item.Failed || blocked[item.ReplyToItem] {
+			blocked[item.ID] = true
+			continue
+		}
+
+		if waiting[item.ReplyToItem] || limited[item.Profile] || (item.RetryAt != nil && time.Now().Before(*item.RetryAt)) {
+			waiting[item.ID] = true
+			limited[item.Profile] = true
+
+			continue
+		}
+
+		if item.ReplyToItem != "" {
+			parent := byID[item.ReplyToItem]
+			if parent == nil || parent.PostID == "" {
+				blocked[item.ID] = true
+				continue
+			}
+
+			item.ReplyTo = parent.PostID
+		}
+
+		stored, err := credentials.Fresh(ctx, item.Profile, clientID, clientSecret)
+		if err != nil {
+			return fmt.Errorf("outbox item %s: %w", item.ID, err)
+		}
+
+		posts, ok := recent[item.Profile]
+		if !ok {
+			posts, _, err = api.GetUserPosts(ctx, stored.UserID, oldestQueued(items, item.Profile).Add(-sentLookback), stored.AccessToken)
+			if api.IsUnavailable(err) {
+				return fmt.Errorf("X is still unreachable, %d post(s) sent: %w", sent, err)
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not check for posts that already went through: %w", err)
+			}
+
+			recent[item.Profile] = posts
+		}
+
+		if postID, found := outbox.FindSent(item, posts, used); found {
+			used[postID] = true
+			item.PostID = postID
+			item.Error = ""
+
+			fmt.Println(prompt.Info("[INFO] "), fmt.Sprintf("outbox item %s was already posted as %s", item.ID, postID))
+		} else {
+			err := checkLength(&item.OutgoingPost, api.MaxPostLength(stored.Verified))
+			if err == nil {
+				var (
+					postResponse *models.PostResponse
+					rateLimit    *models.RateLimitInfo
+				)
+
+				postResponse, rateLimit, err = publish(ctx, &item.OutgoingPost, stored.AccessToken, stored.Verified,
+					settings.Accessibility.AltTextPolicy, uploadOpts)
+				if err == nil {
+					item.PostID = postResponse.Data.ID
+					recordSent(item.Profile, stored.Username, &item.OutgoingPost, item.PostID, rateLimit)
+				}
+			}
+
+			retryAt, rateLimited := api.RetryAt(err)
+
+			switch {
+			case err == nil:
+				item.Error = ""
+				item.RetryAt = nil
+				used[item.PostID] = true
+				sent++
+
+				fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("outbox item %s posted, post ID: %s", item.ID, item.PostID))
+
+			case rateLimited:
+				item.Attempts++
+				item.Error = err.Error()
+				item.RetryAt = &retryAt
+				waiting[item.ID] = true
+				limited[item.Profile] = true
+
+				fmt.Println(prompt.Warn("[WARN] "), fmt.Sprintf("outbox item %s hit the rate limit, it is tried again %s", item.ID, humanize.Time(retryAt)))
+
+			case api.IsUnavailable(err):
+				item.Attempts++
+				item.Error = err.Error()
+
+				if err := outbox.Update(item); err != nil {
+					return err
+				}
+
+				return fmt.Errorf("X is still unreachable, %d post(s) sent: %w", sent, err)
+
+			default:
+				item.Failed = true
+				item.Error = err.Error()
+				blocked[item.ID] = true
+
+				fmt.Println(prompt.Failed("[ERROR] "), fmt.Sprintf("outbox item %s failed:", item.ID), err)
+			}
+		}
+
+		if err := outbox.Update(item); err != nil {
+			return err
+		}
+	}
+
+	if len(blocked) > 0 {
+		fmt.Println(prompt.Warn("[WARN] "), fmt.Sprintf("%d outbox item(s) need attention, see \"x-yapper outbox list\"", len(blocked)))
+	}
+
+	if len(waiting) > 0 {
+		fmt.Println(prompt.Info("[INFO] "), fmt.Sprintf("%d outbox item(s) wait for a rate limit to reset", len(waiting)))
+	}
+
+	return outbox.Prune()
+}
+
+func hasPending(items []*models.OutboxItem) bool {
+	for _, item := range items {
+		if item.PostID == "" && !item.Failed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func oldestQueued(items []*models.OutboxItem, profile string) time.Time {
+	var oldest time.Time
+
+	for _, item := range items {
+		if item.Profile != profile || item.PostID != "" {
+			continue
+		}
+
+		if oldest.IsZero() || item.QueuedAt.Before(oldest) {
+			oldest = item.QueuedAt
+		}
+	}
+
+	return oldest
+}
+
+// flushOnStart sends what is left in the outbox before a command posts
+// anything new. Problems are only reported, they never stop the command.
+func flushOnStart(ctx context.Context, settings *config.Settings, uploadOpts media.UploadOptions) {
+	items, err := outbox.List()
+	if err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), err)
+		return
+	}
+
+	if !hasPending(items) {
+		return
+	}
+
+	fmt.Println(prompt.Info("[INFO] "), "sending posts waiting in the outbox")
+
+	if err := flushOutbox(ctx, settings, uploadOpts); err != nil {
+		fmt.Println(prompt.Warn("[WARN] "), err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/links"
+	"x-dev/internal/lint"
+	"x-dev/internal/models"
+	"x-dev/internal/outbox"
+	"x-dev/internal/prompt"
+)
+
+func runPostCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("post", flag.ContinueOnError)
+	pf := addPostFlags(flags)
+	chunkSizeFlag := flags.String("chunk-size", "", "segment size for chunked video and GIF uploads, e.g. 4MiB")
+	uploadWorkersFlag := flags.Int("upload-workers", 0, "number of parallel segment uploads for videos")
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
+	profilesFlag := flags.String("profiles", "", "send the post from each of these comma-separated profiles at once")
+	templateFlag := flags.String("template", "", "start from a template in the template library, then review it in the editor")
+
+	var vars stringList
+	flags.Var(&vars, "var", "template variable as name=value (repeatable)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	uploadOpts, err := uploadOptions(settings, *chunkSizeFlag, *uploadWorkersFlag)
+	if err != nil {
+		return err
+	}
+
+	uploadOpts.Progress = prompt.PrintUploadProgress
+
+	if *profilesFlag != "" {
+		if *profileFlag != "" {
+			return errors.New("use either --profile or --profiles, not both")
+		}
+
+		return crossPost(ctx, settings, uploadOpts, pf, *templateFlag, vars, strings.Split(*profilesFlag, ","))
+	}
+
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	if len(vars) > 0 && *templateFlag == "" {
+		return errors.New("--var needs --template")
+	}
+
+	if *templateFlag != "" {
+		if *pf.text != "" || *pf.file != "" {
+			return errors.New("use either --template or --text/--file, not both")
+		}
+
+		if *pf.text, err = renderTemplate(*templateFlag, vars); err != nil {
+			return err
+		}
+	}
+
+	out, err := pf.outgoing(profile)
+	if err != nil {
+		return err
+	}
+
+	linter, err := lint.New(profile.Lint)
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", profile.Name, err)
+	}
+
+	sess, err := authenticate(ctx, profile.Name)
+	if err != nil {
+		return err
+	}
+
+	if *templateFlag != "" {
+		attachments, err := prepareAttachments(out, sess.user.Data.Verified, models.AltTextPolicyOff)
+		if err != nil {
+			return err
+		}
+
+		send, err := prompt.ReviewPost(ctx, out, attachments, sess.maxPostLength, prompt.Options{
+			AltTextPolicy: settings.Accessibility.AltTextPolicy,
+			Profile:       profile.Name,
+			Duplicates:    settings.Duplicates,
+			Lint:          linter,
+			KnownSecrets:  knownSecrets(sess.token.AccessToken),
+			Spelling:      spellChecker(settings, profile),
+			Links:         links.New(profile.Links),
+			Communities:   joinedCommunities(ctx, profile, sess.token.AccessToken),
+		})
+		if err != nil || !send {
+			return err
+		}
+	}
+
+	flushOnStart(ctx, settings, uploadOpts)
+
+	if err := checkLength(out, sess.maxPostLength); err != nil {
+		return err
+	}
+
+	// A reviewed template was linted in the preview already.
+	if *templateFlag == "" {
+		findings := linter.Run(lint.Post{Text: out.Text, MaxLength: sess.maxPostLength, Reply: out.ReplyTo != ""})
+		if prompt.PrintFindings(findings) {
+			return errors.New("post not sent, it has lint errors")
+		}
+
+		if err := checkSecrets(out, profile.Name, "post", *pf.allowSecrets, sess.token.AccessToken); err != nil {
+			return err
+		}
+	}
+
+	postResponse, rateLimit, err := publish(ctx, out, sess.token.AccessToken, sess.user.Data.Verified,
+		settings.Accessibility.AltTextPolicy, uploadOpts)
+	if api.IsUnavailable(err) {
+		return queuePost(profile.Name, out, err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	recordSent(profile.Name, sess.user.Data.Username, out, postResponse.Data.ID, rateLimit)
+
+	fmt.Println(prompt.Success("[OK] "), "post successful, post ID:", postResponse.Data.ID)
+
+	return nil
+}
+
+// queuePost keeps a post that could not reach X in the outbox instead of
+// losing it.
+func queuePost(profile string, out *models.OutgoingPost, reason error) error {
+	item := &models.OutboxItem{Profile: profile, OutgoingPost: *out}
+
+	if err := outbox.Add(item); err != nil {
+		return fmt.Errorf("X is unreachable (%v) and the post could not be queued: %w", reason, err)
+	}
+
+	fmt.Println(prompt.Warn("[WARN] "), "X is unreachable:", reason)
+	fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("post saved to the outbox as item %s, send it with \"x-yapper outbox flush\"", item.ID))
+
+	return nil
+}
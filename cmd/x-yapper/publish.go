@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/credentials"
+	"x-dev/internal/links"
+	"x-dev/internal/lint"
+	"x-dev/internal/mastodon"
+	"x-dev/internal/media"
+	"x-dev/internal/models"
+	"x-dev/internal/poll"
+	"x-dev/internal/postref"
+	"x-dev/internal/prompt"
+	"x-dev/internal/secrets"
+	"x-dev/internal/twittertext"
+)
+
+// postFlags are the flags shared by every command that describes a post.
+type postFlags struct {
+	text          *string
+	file          *string
+	mediaPaths    stringList
+	altTexts      stringList
+	pollOptions   stringList
+	pollDuration  *string
+	replyTo       *string
+	quote         *string
+	replySettings *string
+	community     *string
+	allowSecrets  *bool
+}
+
+func addPostFlags(flags *flag.FlagSet) *postFlags {
+	pf := &postFlags{}
+
+	pf.text = flags.String("text", "", "post text")
+	pf.file = flags.String("file", "", "read the post text from a file, or - for stdin")
+	flags.Var(&pf.mediaPaths, "media", "attach a media file (repeatable, up to 4)")
+	flags.Var(&pf.altTexts, "alt-text", "alt text for the media file in the same position (repeatable)")
+	flags.Var(&pf.pollOptions, "poll-option", "add a poll option (repeatable, 2-4 options)")
+	pf.pollDuration = flags.String("poll-duration", "1d", "how long the poll stays open, e.g. 30m, 6h, 3d")
+	pf.replyTo = flags.String("reply-to", "", "reply to a post ID or x.com status URL")
+	pf.quote = flags.String("quote", "", "quote a post ID or x.com status URL")
+	pf.replySettings = flags.String("reply-settings", "", "who can reply: everyone, following or mentionedUsers (default from the profile)")
+	pf.community = flags.String("community", "", "post to an X Community you belong to, by ID or x.com/i/communities URL")
+	pf.allowSecrets = flags.Bool("allow-secrets", false, "send even if the post looks like it contains a secret; the override is logged")
+
+	return pf
+}
+
+func (pf *postFlags) outgoing(profile *config.ProfileSettings) (*models.OutgoingPost, error) {
+	content, err := readPostText(*pf.text, *pf.file)
+	if err != nil {
+		return nil, err
+	}
+
+	content, changes := links.New(profile.Links).Rewrite(content)
+	for _, change := range changes {
+		fmt.Println(prompt.Info("[INFO] "), "link rewritten:", change.From, "->", change.To)
+	}
+
+	out := &models.OutgoingPost{
+		Text:          content,
+		MediaPaths:    pf.mediaPaths,
+		AltTexts:      pf.altTexts,
+		ReplySettings: profile.ReplySettings,
+	}
+
+	if len(pf.altTexts) > len(pf.mediaPaths) {
+		return nil, errors.New("more --alt-text values than --media files")
+	}
+
+	if *pf.replyTo != "" {
+		if out.ReplyTo, err = postref.ParseID(*pf.replyTo); err != nil {
+			return nil, err
+		}
+	}
+
+	if *pf.quote != "" {
+		if out.QuoteID, err = postref.ParseID(*pf.quote); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pf.pollOptions) > 0 {
+		if len(pf.mediaPaths) > 0 {
+			return nil, errors.New("a post can have a poll or media, not both")
+		}
+
+		if out.ReplyTo != "" || out.QuoteID != "" {
+			return nil, errors.New("polls cannot be combined with --reply-to or --quote")
+		}
+
+		if out.Poll, err = poll.New(pf.pollOptions, *pf.pollDuration); err != nil {
+			return nil, err
+		}
+	}
+
+	if *pf.replySettings != "" {
+		if out.ReplyTo != "" {
+			return nil, errors.New("--reply-settings only applies to new posts and quotes, not replies")
+		}
+
+		if out.ReplySettings, err = config.ParseReplySettings(*pf.replySettings); err != nil {
+			return nil, err
+		}
+	}
+
+	if *pf.community != "" {
+		if out.ReplyTo != "" || out.QuoteID != "" {
+			return nil, errors.New("--community only applies to new posts, not replies or quotes")
+		}
+
+		if out.CommunityID, err = postref.ParseCommunityID(*pf.community); err != nil {
+			return nil, err
+		}
+	}
+
+	if out.ReplyTo != "" {
+		out.ReplySettings = ""
+	}
+
+	return out, nil
+}
+
+// prepareAttachments checks the media of out against the account's limits
+// and the alt text policy before anything is uploaded.
+func prepareAttachments(out *models.OutgoingPost, verified bool, altTextPolicy string) ([]*models.MediaAttachment, error) {
+	attachments, err := media.InspectAll(out.MediaPaths, media.LimitsFor(verified))
+	if err != nil {
+		return nil, fmt.Errorf("invalid attachment: %w", err)
+	}
+
+	for i, altText := range out.AltTexts {
+		attachments[i].AltText = strings.TrimSpace(altText)
+	}
+
+	if err := checkAltTextPolicy(attachments, altTextPolicy); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func checkLength(out *models.OutgoingPost, maxPostLength int) error {
+	if twittertext.WeightedLength(out.Text) > maxPostLength {
+		return fmt.Errorf("post exceeds maximum length of %d characters", maxPostLength)
+	}
+
+	return nil
+}
+
+// publish uploads the media of out and sends it as a new post, reply or
+// quote.
+func publish(ctx context.Context, out *models.OutgoingPost, accessToken string, verified bool, altTextPolicy string, uploadOpts media.UploadOptions) (*models.PostResponse, *models.RateLimitInfo, error) {
+	if !out.AllowSecrets {
+		if matches := secrets.Scan(out.Text, knownSecrets(accessToken)); len(matches) > 0 {
+			return nil, nil, &secrets.FoundError{Text: out.Text, Matches: matches}
+		}
+	}
+
+	attachments, err := prepareAttachments(out, verified, altTextPolicy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(attachments) > 0 {
+		err := media.UploadAll(ctx, attachments, accessToken, uploadOpts)
+
+		if uploadOpts.Progress != nil {
+			fmt.Println()
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("media upload failed: %w", err)
+		}
+	}
+
+	if out.ReplyTo == "" && out.QuoteID == "" {
+		return api.SendPost(ctx, &models.Post{
+			Text:          out.Text,
+			Media:         media.MediaIDs(attachments),
+			Poll:          out.Poll,
+			ReplySettings: out.ReplySettings,
+			CommunityID:   out.CommunityID,
+		}, accessToken)
+	}
+
+	replyPost := &models.ReplyPost{
+		Text:          out.Text,
+		Media:         media.MediaIDs(attachments),
+		QuoteTweetID:  out.QuoteID,
+		ReplySettings: out.ReplySettings,
+	}
+
+	if out.ReplyTo != "" {
+		replyPost.Reply = &models.Reply{ReplyID: out.ReplyTo, ExcludeReplyUserIDs: out.ExcludeReplyUserIDs}
+	}
+
+	return api.SendReplyPost(ctx, replyPost, accessToken)
+}
+
+// knownSecrets returns x-yapper's own credentials, which must never end up
+// in a post.
+func knownSecrets(accessToken string) []string {
+	known := []string{accessToken}
+
+	if _, clientSecret, err := config.LoadClientConfig(); err == nil {
+		known = append(known, clientSecret)
+	}
+
+	if tokens, err := credentials.Secrets(); err == nil {
+		known = append(known, tokens...)
+	}
+
+	if tokens, err := mastodon.Secrets(); err == nil {
+		known = append(known, tokens...)
+	}
+
+	return known
+}
+
+// checkSecrets scans out before it is sent or stored for later. With allow
+// set a hit is logged and out is marked, so later sends do not stop on it.
+func checkSecrets(out *models.OutgoingPost, profile string, source string, allow bool, accessToken string) error {
+	matches := secrets.Scan(out.Text, knownSecrets(accessToken))
+	if len(matches) == 0 {
+		return nil
+	}
+
+	if !allow {
+		fmt.Println(prompt.HighlightSecrets(out.Text, matches))
+		return secretsError(&secrets.FoundError{Text: out.Text, Matches: matches})
+	}
+
+	if err := secrets.LogOverride(profile, source, out.Text, matches); err != nil {
+		return err
+	}
+
+	fmt.Println(prompt.Warn("[WARN] "), "sending despite a possible secret:", secrets.Kinds(matches), "- the override was logged")
+
+	out.AllowSecrets = true
+
+	return nil
+}
+
+// checkLint runs the lint rules of profile on a post before it is queued,
+// so an error holds back a scheduled post just as it holds back one sent
+// right away.
+func checkLint(profile *config.ProfileSettings, out *models.OutgoingPost, attachments []*models.MediaAttachment, maxPostLength int) error {
+	linter, err := lint.New(profile.Lint)
+	if err != nil {
+		return fmt.Errorf("profile %q: %w", profile.Name, err)
+	}
+
+	findings := linter.Run(lint.Post{
+		Text:        out.Text,
+		Attachments: attachments,
+		MaxLength:   maxPostLength,
+		Reply:       out.ReplyTo != "",
+	})
+
+	if prompt.PrintFindings(findings) {
+		return errors.New("post not scheduled, it has lint errors")
+	}
+
+	return nil
+}
+
+func secretsError(err error) error {
+	return fmt.Errorf("%w; check the text or pass --allow-secrets to send it anyway", err)
+}
+
+func readPostText(text string, file string) (string, error) {
+	if file == "" {
+		if strings.TrimSpace(text) == "" {
+			return "", errors.New("post text is required, use --text or --file")
+		}
+
+		return strings.TrimSpace(text), nil
+	}
+
+	if text != "" {
+		return "", errors.New("use either --text or --file, not both")
+	}
+
+	var data []byte
+	var err error
+
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error reading post text: %w", err)
+	}
+
+	content := strings.TrimSpace(string(data))
+	if content == "" {
+		return "", errors.New("post text is empty")
+	}
+
+	return content, nil
+}
+
+func checkAltTextPolicy(attachments []*models.MediaAttachment, policy string) error {
+	for _, attachment := range attachments {
+		if !media.NeedsAltText(attachment) {
+			continue
+		}
+
+		switch policy {
+		case models.AltTextPolicyRequire:
+			return fmt.Errorf("%s has no alt text, use --alt-text", filepath.Base(attachment.Path))
+		case models.AltTextPolicyWarn:
+			fmt.Println(prompt.Warn("[WARN] "), filepath.Base(attachment.Path), "has no alt text")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/credentials"
+	"x-dev/internal/models"
+	"x-dev/internal/prompt"
+	"x-dev/internal/schedule"
+	"x-dev/internal/templates"
+)
+
+func scheduleRecurring(ctx context.Context, settings *config.Settings, profile *config.ProfileSettings, out *models.OutgoingPost, cronExpr string, timeZone string, onDuplicate string, allowSecrets bool) error {
+	if out.Poll != nil || out.ReplyTo != "" || out.QuoteID != "" {
+		return errors.New("recurring posts cannot be polls, replies or quotes")
+	}
+
+	if err := validateDuplicatePolicy(onDuplicate); err != nil {
+		return err
+	}
+
+	post := &models.RecurringPost{
+		Profile:         profile.Name,
+		Cron:            cronExpr,
+		TimeZone:        timeZone,
+		Template:        out.Text,
+		AltTexts:        out.AltTexts,
+		ReplySettings:   out.ReplySettings,
+		CommunityID:     out.CommunityID,
+		DuplicatePolicy: onDuplicate,
+	}
+
+	if err := planNextRun(post, time.Now()); err != nil {
+		return err
+	}
+
+	stored, err := storedLogin(ctx, profile.Name)
+	if err != nil {
+		return err
+	}
+
+	text, err := checkRecurring(post, stored.Verified)
+	if err != nil {
+		return err
+	}
+
+	out.Text = text
+
+	// Later runs render the template differently, but the override stands
+	// for the entry as a whole.
+	if err := checkSecrets(out, profile.Name, "schedule", allowSecrets, stored.AccessToken); err != nil {
+		return err
+	}
+
+	post.AllowSecrets = out.AllowSecrets
+
+	attachments, err := prepareAttachments(out, stored.Verified, settings.Accessibility.AltTextPolicy)
+	if err != nil {
+		return err
+	}
+
+	// As with secrets, the first run stands in for the template.
+	if err := checkLint(profile, out, attachments, api.MaxPostLength(stored.Verified)); err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		post.MediaPaths = append(post.MediaPaths, attachment.Path)
+	}
+
+	if err := schedule.AddRecurring(post); err != nil {
+		return err
+	}
+
+	fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("recurring post %s scheduled, first run %s", post.ID, schedule.FormatTime(post.Next, post.TimeZone)))
+	fmt.Println("------------------------------------------------------------")
+	fmt.Println(text)
+	fmt.Println("------------------------------------------------------------")
+
+	return nil
+}
+
+func showRecurring(id string) error {
+	post, err := schedule.GetRecurring(id)
+	if err != nil {
+		return err
+	}
+
+	zone := post.TimeZone
+	if zone == "" {
+		zone = "local"
+	}
+
+	fmt.Printf("ID:        %s\nProfile:   %s\nRepeats:   %s (%s)\nNext run:  %s\nRuns:      %d\n",
+		post.ID, post.Profile, post.Cron, zone, schedule.FormatTime(post.Next, post.TimeZone), post.Runs)
+
+	if post.LastRun != nil {
+		fmt.Printf("Last run:  %s, post ID %s\n", schedule.FormatTime(*post.LastRun, post.TimeZone), post.LastPostID)
+	}
+
+	if post.CommunityID != "" {
+		fmt.Printf("Community: %s\n", communityLabel(post.CommunityID))
+	}
+
+	for i, path := range post.MediaPaths {
+		fmt.Printf("Media %d:   %s\n", i+1, path)
+	}
+
+	if post.Error != "" {
+		fmt.Printf("Error:     %s\n", post.Error)
+	}
+
+	fmt.Println("Template:")
+	fmt.Println("------------------------------------------------------------")
+	fmt.Println(post.Template)
+	fmt.Println("------------------------------------------------------------")
+
+	text, err := renderRecurring(post)
+	if err != nil {
+		fmt.Println(prompt.Failed("[ERROR] "), err)
+		return nil
+	}
+
+	fmt.Println("Next post:")
+	fmt.Println("------------------------------------------------------------")
+	fmt.Println(text)
+	fmt.Println("------------------------------------------------------------")
+
+	return nil
+}
+
+func editRecurring(ctx context.Context, id string, cronExpr string, timeZone string, onDuplicate string, text string, file string, allowSecrets bool) error {
+	post, err := schedule.GetRecurring(id)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+
+	if cronExpr != "" {
+		post.Cron = cronExpr
+		changed = true
+	}
+
+	if timeZone != "" {
+		post.TimeZone = timeZone
+		changed = true
+	}
+
+	if onDuplicate != "" {
+		if err := validateDuplicatePolicy(onDuplicate); err != nil {
+			return err
+		}
+
+		post.DuplicatePolicy = onDuplicate
+		changed = true
+	}
+
+	textChanged := false
+
+	if text != "" || file != "" {
+		if post.Template, err = readPostText(text, file); err != nil {
+			return err
+		}
+
+		changed = true
+		textChanged = true
+	}
+
+	if !changed {
+		if post.Template, err = editText(ctx, post.Template); err != nil {
+			return err
+		}
+
+		textChanged = true
+	}
+
+	if err := planNextRun(post, time.Now()); err != nil {
+		return err
+	}
+
+	stored, err := credentials.Load(post.Profile)
+	if err != nil {
+		return err
+	}
+
+	rendered, err := checkRecurring(post, stored.Verified)
+	if err != nil {
+		return err
+	}
+
+	profile, err := storedProfile(post.Profile)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLint(profile, &models.OutgoingPost{Text: rendered}, nil, api.MaxPostLength(stored.Verified)); err != nil {
+		return err
+	}
+
+	if textChanged {
+		out := &models.OutgoingPost{Text: rendered}
+		if err := checkSecrets(out, post.Profile, "schedule", allowSecrets, stored.AccessToken); err != nil {
+			return err
+		}
+
+		post.AllowSecrets = out.AllowSecrets
+	}
+
+	post.Attempts = 0
+	post.Error = ""
+
+	if err := schedule.UpdateRecurring(post); err != nil {
+		return err
+	}
+
+	fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("recurring post %s updated, next run %s", post.ID, schedule.FormatTime(post.Next, post.TimeZone)))
+
+	return nil
+}
+
+// planNextRun sets Next to the first occurrence of the entry's cron
+// expression after the given time.
+func planNextRun(post *models.RecurringPost, after time.Time) error {
+	cron, err := schedule.ParseCron(post.Cron)
+	if err != nil {
+		return err
+	}
+
+	loc, err := schedule.LoadLocation(post.TimeZone)
+	if err != nil {
+		return err
+	}
+
+	if post.Next, err = cron.Next(after, loc); err != nil {
+		return fmt.Errorf("%q: %w", post.Cron, err)
+	}
+
+	return nil
+}
+
+// renderRecurring renders the template for the entry's next run.
+func renderRecurring(post *models.RecurringPost) (string, error) {
+	loc, err := schedule.LoadLocation(post.TimeZone)
+	if err != nil {
+		return "", err
+	}
+
+	text, err := templates.Render(post.Template, templates.Data{Time: post.Next.In(loc), Run: post.Runs + 1})
+	if err != nil {
+		return "", err
+	}
+
+	if text == "" {
+		return "", errors.New("the template rendered an empty post")
+	}
+
+	return text, nil
+}
+
+// checkRecurring renders the next run of post and checks it fits, so
+// template mistakes show up when the entry is created rather than when it
+// is due.
+func checkRecurring(post *models.RecurringPost, verified bool) (string, error) {
+	text, err := renderRecurring(post)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkLength(&models.OutgoingPost{Text: text}, api.MaxPostLength(verified)); err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+func validateDuplicatePolicy(policy string) error {
+	switch policy {
+	case models.DuplicatePolicySkip, models.DuplicatePolicyAlert:
+		return nil
+	default:
+		return fmt.Errorf("invalid --on-duplicate %q, use skip or alert", policy)
+	}
+}
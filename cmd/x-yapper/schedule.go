@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/credentials"
+	"x-dev/internal/models"
+	"x-dev/internal/prompt"
+	"x-dev/internal/schedule"
+)
+
+func runScheduleCommand(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "list":
+			return scheduleList(args[1:])
+		case "show":
+			return scheduleShow(args[1:])
+		case "edit":
+			return scheduleEdit(ctx, args[1:])
+		case "remove":
+			return scheduleRemove(args[1:])
+		}
+	}
+
+	return scheduleAdd(ctx, args)
+}
+
+func scheduleAdd(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("schedule", flag.ContinueOnError)
+	pf := addPostFlags(flags)
+	at := flags.String("at", "", "when to send: \"2025-03-10 09:00\", \"tomorrow 09:00\", \"17:30\", \"+2h\" or RFC 3339")
+	cronExpr := flags.String("cron", "", "repeat on a 5-field cron expression, e.g. \"0 9 * * 1\"; the text is a template")
+	onDuplicate := flags.String("on-duplicate", models.DuplicatePolicyAlert, "when a run renders the same text as the last one: skip or alert")
+	timeZone := flags.String("tz", "", "time zone for --at and --cron, e.g. America/New_York (default local)")
+	profileFlag := flags.String("profile", "", "profile from config.json to use")
+
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), `Usage:
+  x-yapper schedule --at TIME [post flags]   queue a post
+  x-yapper schedule --cron EXPR [post flags] queue a recurring post
+  x-yapper schedule list [--all]             show queued posts
+  x-yapper schedule show ID                  show one queued post
+  x-yapper schedule edit ID [flags]          change time or text of a queued post
+  x-yapper schedule remove ID                remove a queued post
+
+Flags:`)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if (*at == "") == (*cronExpr == "") {
+		flags.Usage()
+		return errors.New("use either --at or --cron")
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	profile, err := settings.Profile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	out, err := pf.outgoing(profile)
+	if err != nil {
+		return err
+	}
+
+	if *cronExpr != "" {
+		return scheduleRecurring(ctx, settings, profile, out, *cronExpr, *timeZone, *onDuplicate, *pf.allowSecrets)
+	}
+
+	when, err := scheduledTime(*at, *timeZone)
+	if err != nil {
+		return err
+	}
+
+	stored, err := storedLogin(ctx, profile.Name)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLength(out, api.MaxPostLength(stored.Verified)); err != nil {
+		return err
+	}
+
+	if err := checkSecrets(out, profile.Name, "schedule", *pf.allowSecrets, stored.AccessToken); err != nil {
+		return err
+	}
+
+	attachments, err := prepareAttachments(out, stored.Verified, settings.Accessibility.AltTextPolicy)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLint(profile, out, attachments, api.MaxPostLength(stored.Verified)); err != nil {
+		return err
+	}
+
+	out.MediaPaths = out.MediaPaths[:0]
+	for _, attachment := range attachments {
+		out.MediaPaths = append(out.MediaPaths, attachment.Path)
+	}
+
+	post := &models.ScheduledPost{
+		Profile:      profile.Name,
+		At:           when,
+		TimeZone:     *timeZone,
+		OutgoingPost: *out,
+	}
+
+	if err := schedule.Add(post); err != nil {
+		return err
+	}
+
+	fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("scheduled post %s for %s", post.ID, schedule.FormatTime(post.At, post.TimeZone)))
+	fmt.Println(prompt.Info("[INFO] "), "queued posts are sent by \"x-yapper scheduler run\"")
+
+	return nil
+}
+
+func scheduleList(args []string) error {
+	flags := flag.NewFlagSet("schedule list", flag.ContinueOnError)
+	all := flags.Bool("all", false, "include sent, failed and skipped posts")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	posts, err := schedule.List(*all)
+	if err != nil {
+		return err
+	}
+
+	recurring, err := schedule.ListRecurring()
+	if err != nil {
+		return err
+	}
+
+	if len(posts) == 0 && len(recurring) == 0 {
+		fmt.Println(prompt.Info("[INFO] "), "no scheduled posts")
+		return nil
+	}
+
+	for _, post := range posts {
+		fmt.Printf("%-4s %-8s %-10s %s\n     %s\n", post.ID, post.Status, post.Profile,
+			schedule.FormatTime(post.At, post.TimeZone), summarize(post.Text, 60))
+	}
+
+	for _, post := range recurring {
+		status := "repeats"
+		if post.Error != "" {
+			status = "error"
+		}
+
+		fmt.Printf("%-4s %-8s %-10s %q, next %s\n     %s\n", post.ID, status, post.Profile, post.Cron,
+			schedule.FormatTime(post.Next, post.TimeZone), summarize(post.Template, 60))
+	}
+
+	return nil
+}
+
+func scheduleShow(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: x-yapper schedule show ID")
+	}
+
+	if schedule.IsRecurring(args[0]) {
+		return showRecurring(args[0])
+	}
+
+	post, err := schedule.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ID:        %s\nStatus:    %s\nProfile:   %s\nSend at:   %s\n",
+		post.ID, post.Status, post.Profile, schedule.FormatTime(post.At, post.TimeZone))
+
+	if post.ReplyTo != "" {
+		fmt.Printf("Reply to:  %s\n", post.ReplyTo)
+	}
+
+	if post.QuoteID != "" {
+		fmt.Printf("Quoting:   %s\n", post.QuoteID)
+	}
+
+	if post.CommunityID != "" {
+		fmt.Printf("Community: %s\n", communityLabel(post.CommunityID))
+	}
+
+	for i, path := range post.MediaPaths {
+		fmt.Printf("Media %d:   %s\n", i+1, path)
+	}
+
+	if post.Poll != nil {
+		fmt.Printf("Poll:      %s\n", strings.Join(post.Poll.Options, " / "))
+	}
+
+	if post.PostID != "" {
+		fmt.Printf("Post ID:   %s\n", post.PostID)
+	}
+
+	if post.Error != "" {
+		fmt.Printf("Error:     %s (after %d attempt(s))\n", post.Error, post.Attempts)
+	}
+
+	fmt.Println("------------------------------------------------------------")
+	fmt.Println(post.Text)
+
+	return nil
+}
+
+func scheduleEdit(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("usage: x-yapper schedule edit ID [--at TIME] [--tz ZONE] [--text TEXT | --file FILE]")
+	}
+
+	flags := flag.NewFlagSet("schedule edit", flag.ContinueOnError)
+	at := flags.String("at", "", "new send time")
+	cronExpr := flags.String("cron", "", "new cron expression for a recurring post")
+	onDuplicate := flags.String("on-duplicate", "", "for a recurring post: skip or alert on a repeated text")
+	timeZone := flags.String("tz", "", "time zone for --at or --cron (default: the post's current zone)")
+	text := flags.String("text", "", "new post text")
+	file := flags.String("file", "", "read the new post text from a file, or - for stdin")
+	allowSecrets := flags.Bool("allow-secrets", false, "keep the post even if the new text looks like it contains a secret; the override is logged")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if schedule.IsRecurring(args[0]) {
+		if *at != "" {
+			return errors.New("recurring posts take --cron, not --at")
+		}
+
+		return editRecurring(ctx, args[0], *cronExpr, *timeZone, *onDuplicate, *text, *file, *allowSecrets)
+	}
+
+	if *cronExpr != "" || *onDuplicate != "" {
+		return errors.New("--cron and --on-duplicate only apply to recurring posts")
+	}
+
+	post, err := schedule.Get(args[0])
+	if err != nil {
+		return err
+	}
+
+	switch post.Status {
+	case models.ScheduleStatusSent:
+		return fmt.Errorf("post %s was already sent as %s", post.ID, post.PostID)
+	case models.ScheduleStatusSending:
+		return fmt.Errorf("post %s is being sent", post.ID)
+	}
+
+	changed := false
+
+	if *at != "" {
+		zone := post.TimeZone
+		if *timeZone != "" {
+			zone = *timeZone
+		}
+
+		if post.At, err = scheduledTime(*at, zone); err != nil {
+			return err
+		}
+
+		post.TimeZone = zone
+		changed = true
+	}
+
+	textChanged := false
+
+	if *text != "" || *file != "" {
+		if post.Text, err = readPostText(*text, *file); err != nil {
+			return err
+		}
+
+		changed = true
+		textChanged = true
+	}
+
+	if !changed {
+		if post.Text, err = editText(ctx, post.Text); err != nil {
+			return err
+		}
+
+		textChanged = true
+	}
+
+	stored, err := credentials.Load(post.Profile)
+	if err != nil {
+		return err
+	}
+
+	profile, err := storedProfile(post.Profile)
+	if err != nil {
+		return err
+	}
+
+	if err := checkLength(&post.OutgoingPost, api.MaxPostLength(stored.Verified)); err != nil {
+		return err
+	}
+
+	if err := checkLint(profile, &post.OutgoingPost, nil, api.MaxPostLength(stored.Verified)); err != nil {
+		return err
+	}
+
+	// An override covers the text it was given for, not a new one.
+	if textChanged {
+		post.AllowSecrets = false
+
+		if err := checkSecrets(&post.OutgoingPost, post.Profile, "schedule", *allowSecrets, stored.AccessToken); err != nil {
+			return err
+		}
+	}
+
+	// Editing a failed or skipped post queues it again.
+	if err := schedule.Edit(post); err != nil {
+		return err
+	}
+
+	fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("post %s scheduled for %s", post.ID, schedule.FormatTime(post.At, post.TimeZone)))
+
+	return nil
+}
+
+func editText(ctx context.Context, text string) (string, error) {
+	editor, err := config.NewEditorConfig().ChooseEditor()
+	if err != nil {
+		return "", fmt.Errorf("editor initialization failed: %w", err)
+	}
+
+	content, err := editor.EditContent(ctx, text)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return "", errors.New("post text is empty, use \"schedule remove\" to drop the post")
+	}
+
+	return strings.TrimSpace(content), nil
+}
+
+func scheduleRemove(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: x-yapper schedule remove ID")
+	}
+
+	if err := schedule.Remove(args[0]); err != nil {
+		return err
+	}
+
+	fmt.Println(prompt.Success("[OK] "), "removed scheduled post", args[0])
+
+	return nil
+}
+
+func scheduledTime(at string, timeZone string) (time.Time, error) {
+	loc, err := schedule.LoadLocation(timeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+
+	when, err := schedule.ParseTime(at, loc, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !when.After(now) {
+		return time.Time{}, fmt.Errorf("%s is in the past", when.In(loc).Format("Mon Jan 2 15:04 MST"))
+	}
+
+	return when, nil
+}
+
+// storedProfile returns the settings of the profile a queued post was
+// stored under.
+func storedProfile(name string) (*config.ProfileSettings, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	return settings.Profile(name)
+}
+
+// storedLogin returns the saved login for profile, signing in through the
+// browser first when there is none yet.
+func storedLogin(ctx context.Context, profile string) (*models.StoredToken, error) {
+	if stored, err := credentials.Load(profile); err == nil {
+		return stored, nil
+	}
+
+	fmt.Println(prompt.Info("[INFO] "), fmt.Sprintf("no stored login for profile %q, signing in", profile))
+
+	if _, err := authenticate(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	return credentials.Load(profile)
+}
+
+func summarize(text string, limit int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/credentials"
+	"x-dev/internal/media"
+	"x-dev/internal/models"
+	"x-dev/internal/prompt"
+	"x-dev/internal/schedule"
+	"x-dev/internal/secrets"
+	"x-dev/internal/store"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	schedulerLockFile = "scheduler.lock"
+
+	// maxSendAttempts is how many ticks in a row a scheduled post may fail
+	// before it is marked failed and left for the user to fix.
+	maxSendAttempts = 3
+)
+
+func runSchedulerCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "run" {
+		return errors.New("usage: x-yapper scheduler run [--once]")
+	}
+
+	flags := flag.NewFlagSet("scheduler run", flag.ContinueOnError)
+	once := flags.Bool("once", false, "send what is due now and exit, e.g. from cron")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	uploadOpts, err := uploadOptions(settings, "", 0)
+	if err != nil {
+		return err
+	}
+
+	clientID, clientSecret, err := config.LoadClientConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	interval := settings.Scheduler.IntervalDuration()
+
+	lock, err := store.AcquireLock(schedulerLockFile, max(3*interval, time.Minute))
+	if err != nil {
+		return fmt.Errorf("another scheduler is running: %w", err)
+	}
+
+	defer func() {
+		if err := lock.Release(); err != nil {
+			fmt.Println(prompt.Warn("[WARN] "), err)
+		}
+	}()
+
+	if !*once {
+		fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("scheduler started, checking every %s", interval))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := lock.Touch(); err != nil {
+			fmt.Println(prompt.Warn("[WARN] "), err)
+		}
+
+		if err := sendDuePosts(ctx, settings, clientID, clientSecret, uploadOpts); err != nil {
+			fmt.Println(prompt.Failed("[ERROR] "), err)
+		}
+
+		if *once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Println(prompt.Info("[INFO] "), "scheduler stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func sendDuePosts(ctx context.Context, settings *config.Settings, clientID string, clientSecret string, uploadOpts media.UploadOptions) error {
+	now := time.Now()
+
+	due, err := schedule.Due(now)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range due {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		post, claimed, err := schedule.Claim(entry.ID, now)
+		if err != nil {
+			return err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		late := now.Sub(post.At)
+
+		if late > settings.Scheduler.GraceDuration() && settings.Scheduler.MissedPolicy == config.MissedPolicySkip {
+			post.Status = models.ScheduleStatusSkipped
+			post.Error = "missed by " + strings.TrimSpace(humanize.RelTime(post.At, now, "", ""))
+
+			fmt.Println(prompt.Warn("[WARN] "), fmt.Sprintf("skipped post %s, %s", post.ID, post.Error))
+		} else {
+			sendScheduledPost(ctx, settings, clientID, clientSecret, uploadOpts, post)
+		}
+
+		if err := schedule.Finish(post); err != nil {
+			return err
+		}
+	}
+
+	recurring, err := schedule.DueRecurring(now)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range recurring {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		runRecurring(ctx, settings, clientID, clientSecret, uploadOpts, post, now)
+
+		if err := schedule.UpdateRecurring(post); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runRecurring handles one due occurrence of a recurring post and plans the
+// next one. A failed send is retried on the following ticks before the
+// occurrence is given up.
+func runRecurring(ctx context.Context, settings *config.Settings, clientID string, clientSecret string, uploadOpts media.UploadOptions, post *models.RecurringPost, now time.Time) {
+	occurrence := post.Next
+
+	switch text, err := renderRecurring(post); {
+	case now.Sub(occurrence) > settings.Scheduler.GraceDuration() && settings.Scheduler.MissedPolicy == config.MissedPolicySkip:
+		fmt.Println(prompt.Warn("[WARN] "), fmt.Sprintf("skipped a missed run of %s, it was due %s", post.ID, schedule.FormatTime(occurrence, post.TimeZone)))
+
+	case err != nil:
+		post.Error = err.Error()
+		fmt.Println(prompt.Failed("[ERROR] "), fmt.Sprintf("recurring post %s: %v", post.ID, err))
+
+	case text == post.LastText:
+		if post.DuplicatePolicy == models.DuplicatePolicySkip {
+			fmt.Println(prompt.Info("[INFO] "), fmt.Sprintf("skipped a run of %s, the text is the same as last time", post.ID))
+			break
+		}
+
+		loc, err := schedule.LoadLocation(post.TimeZone)
+		if err != nil {
+			loc = time.Local
+		}
+
+		post.Error = fmt.Sprintf("run at %s not sent, the text is the same as the previous post", occurrence.In(loc).Format("Mon Jan 2 15:04 MST"))
+		fmt.Println(prompt.Warn("[WARN] "), fmt.Sprintf("recurring post %s: %s", post.ID, post.Error))
+
+	default:
+		post.Attempts++
+
+		postID, err := sendOutgoing(ctx, settings, clientID, clientSecret, uploadOpts, post.Profile, &models.OutgoingPost{
+			Text:          text,
+			MediaPaths:    post.MediaPaths,
+			AltTexts:      post.AltTexts,
+			ReplySettings: post.ReplySettings,
+			CommunityID:   post.CommunityID,
+			AllowSecrets:  post.AllowSecrets,
+		})
+		if err != nil {
+			post.Error = err.Error()
+			fmt.Println(prompt.Failed("[ERROR] "), fmt.Sprintf("recurring post %s (attempt %d): %v", post.ID, post.Attempts, err))
+
+			// Retrying does not make a secret go away.
+			var found *secrets.FoundError
+			if post.Attempts < maxSendAttempts && !errors.As(err, &found) {
+				return
+			}
+
+			break
+		}
+
+		sentAt := time.Now()
+
+		post.Runs++
+		post.LastText = text
+		post.LastPostID = postID
+		post.LastRun = &sentAt
+		post.Error = ""
+
+		fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("sent recurring post %s, post ID: %s", post.ID, postID))
+	}
+
+	post.Attempts = 0
+
+	// Plan from now rather than from the occurrence, so a scheduler that was
+	// down for a while sends one catch-up post instead of the whole backlog.
+	if err := planNextRun(post, now); err != nil {
+		post.Error = err.Error()
+		fmt.Println(prompt.Failed("[ERROR] "), fmt.Sprintf("recurring post %s: %v", post.ID, err))
+	}
+}
+
+// sendOutgoing publishes out for profile with its stored login.
+func sendOutgoing(ctx context.Context, settings *config.Settings, clientID string, clientSecret string, uploadOpts media.UploadOptions, profile string, out *models.OutgoingPost) (string, error) {
+	stored, err := credentials.Fresh(ctx, profile, clientID, clientSecret)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkLength(out, api.MaxPostLength(stored.Verified)); err != nil {
+		return "", err
+	}
+
+	postResponse, rateLimit, err := publish(ctx, out, stored.AccessToken, stored.Verified, settings.Accessibility.AltTextPolicy, uploadOpts)
+	if err != nil {
+		return "", err
+	}
+
+	recordSent(profile, stored.Username, out, postResponse.Data.ID, rateLimit)
+
+	return postResponse.Data.ID, nil
+}
+
+func sendScheduledPost(ctx context.Context, settings *config.Settings, clientID string, clientSecret string, uploadOpts media.UploadOptions, post *models.ScheduledPost) {
+	post.Attempts++
+
+	postID, err := sendOutgoing(ctx, settings, clientID, clientSecret, uploadOpts, post.Profile, &post.OutgoingPost)
+	if err != nil {
+		post.Status = models.ScheduleStatusPending
+		post.Error = err.Error()
+
+		var found *secrets.FoundError
+		if post.Attempts >= maxSendAttempts || errors.As(err, &found) {
+			post.Status = models.ScheduleStatusFailed
+		}
+
+		fmt.Println(prompt.Failed("[ERROR] "), fmt.Sprintf("post %s (attempt %d): %v", post.ID, post.Attempts, err))
+
+		return
+	}
+
+	sentAt := time.Now()
+
+	post.Status = models.ScheduleStatusSent
+	post.PostID = postID
+	post.SentAt = &sentAt
+	post.Error = ""
+
+	fmt.Println(prompt.Success("[OK] "), fmt.Sprintf("sent scheduled post %s, post ID: %s", post.ID, postID))
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"x-dev/internal/config"
+	"x-dev/internal/prompt"
+	"x-dev/internal/templates"
+)
+
+func runTemplateCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: x-yapper template list | show NAME | edit NAME")
+	}
+
+	switch args[0] {
+	case "list":
+		return templateList()
+	case "show":
+		if len(args) != 2 {
+			return errors.New("usage: x-yapper template show NAME")
+		}
+
+		return templateShow(args[1])
+	case "edit":
+		if len(args) != 2 {
+			return errors.New("usage: x-yapper template edit NAME")
+		}
+
+		return templateEdit(ctx, args[1])
+	default:
+		return fmt.Errorf("unknown template command %q, use list, show or edit", args[0])
+	}
+}
+
+func templateList() error {
+	names, err := templates.List()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		fmt.Println(prompt.Info("[INFO] "), "no templates, create one with \"x-yapper template edit NAME\"")
+		return nil
+	}
+
+	for _, name := range names {
+		body, err := templates.Load(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%-20s %s\n", name, summarize(body, 50))
+	}
+
+	return nil
+}
+
+func templateShow(name string) error {
+	body, err := templates.Load(name)
+	if err != nil {
+		return err
+	}
+
+	path, err := templates.Path(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Template:  %s\nFile:      %s\n", name, path)
+
+	if vars, err := templates.Variables(body); err != nil {
+		fmt.Println(prompt.Failed("[ERROR] "), err)
+	} else if len(vars) > 0 {
+		fmt.Printf("Variables: %s\n", strings.Join(vars, ", "))
+	}
+
+	fmt.Println("------------------------------------------------------------")
+	fmt.Println(strings.TrimSpace(body))
+	fmt.Println("------------------------------------------------------------")
+
+	return nil
+}
+
+// templateEdit opens a template in the editor, creating it when it does
+// not exist yet. A template that does not parse is reopened until it does
+// or the buffer is emptied.
+func templateEdit(ctx context.Context, name string) error {
+	if _, err := templates.Path(name); err != nil {
+		return err
+	}
+
+	body, err := templates.Load(name)
+	if err != nil {
+		body = ""
+	}
+
+	editor, err := config.NewEditorConfig().ChooseEditor()
+	if err != nil {
+		return fmt.Errorf("editor initialization failed: %w", err)
+	}
+
+	for {
+		content, err := editor.EditContent(ctx, body)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(content) == "" {
+			fmt.Println(prompt.Info("[INFO] "), "template is empty, nothing saved")
+			return nil
+		}
+
+		body = strings.TrimSpace(content) + "\n"
+
+		if _, err := templates.Variables(body); err != nil {
+			fmt.Println(prompt.Failed("[ERROR] "), err, "- reopening the editor")
+			continue
+		}
+
+		break
+	}
+
+	if err := templates.Save(name, body); err != nil {
+		return err
+	}
+
+	fmt.Println(prompt.Success("[OK] "), "template", name, "saved")
+
+	return nil
+}
+
+// renderTemplate renders a library template with the --var values of a
+// command.
+func renderTemplate(name string, vars []string) (string, error) {
+	body, err := templates.Load(name)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := parseVars(vars)
+	if err != nil {
+		return "", err
+	}
+
+	variables, err := templates.Variables(body)
+	if err != nil {
+		return "", fmt.Errorf("template %s: %w", name, err)
+	}
+
+	var missing []string
+
+	for _, variable := range variables {
+		if _, ok := values[variable]; !ok {
+			missing = append(missing, "--var "+variable+"=...")
+		}
+	}
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("template %s needs %s", name, strings.Join(missing, " "))
+	}
+
+	text, err := templates.Render(body, templates.Data{Time: time.Now(), Vars: values})
+	if err != nil {
+		return "", fmt.Errorf("template %s: %w", name, err)
+	}
+
+	return text, nil
+}
+
+// parseVars reads the name=value pairs of --var flags.
+func parseVars(vars []string) (map[string]string, error) {
+	values := make(map[string]string, len(vars))
+
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --var %q, use name=value", v)
+		}
+
+		values[strings.TrimSpace(key)] = value
+	}
+
+	return values, nil
+}
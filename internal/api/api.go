@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -25,6 +27,10 @@ const (
 	tknEndpoint      = "https://api.twitter.com/2/oauth2/token"
 	callbackPort     = "8080"
 	callbackEndpoint = "/callback"
+
+	// rateLimitWindow is the length of X's rate-limit windows, used when
+	// a rate-limited response does not say when the limit resets.
+	rateLimitWindow = 15 * time.Minute
 )
 
 func StartCallbackServer(ctx context.Context, wGroup *sync.WaitGroup, authState string) {
@@ -139,24 +145,96 @@ func CheckAccountType(ctx context.Context, accessToken string) (int, models.User
 		return 0, models.UserResponse{}, fmt.Errorf("error decoding user response: %w", err)
 	}
 
-	var maxPostLength int
-	if userResp.Data.Verified {
-		maxPostLength = 4000
-	} else {
-		maxPostLength = 280
+	return MaxPostLength(userResp.Data.Verified), userResp, nil
+}
+
+// MaxPostLength is the longest post, in weighted characters, an account
+// may send; verified accounts get longer posts.
+func MaxPostLength(verified bool) int {
+	if verified {
+		return 4000
+	}
+
+	return 280
+}
+
+// PostAPIError is a response X sent with an error status. ResetTime is
+// when the rate limit X reported resets, if it reported one.
+type PostAPIError struct {
+	StatusCode int
+	Body       string
+	ResetTime  time.Time
+}
+
+func (e *PostAPIError) Error() string {
+	return fmt.Sprintf("error posting, status code: %d, response: %s", e.StatusCode, e.Body)
+}
+
+// IsUnavailable reports whether err means X could not be reached or was
+// temporarily unable to handle the request, as opposed to rejecting it.
+// Such requests, rate-limited ones included, are worth repeating later.
+func IsUnavailable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if _, limited := RetryAt(err); limited {
+		return true
+	}
+
+	var postErr *PostAPIError
+	if errors.As(err, &postErr) {
+		return postErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var mediaErr *MediaAPIError
+	if errors.As(err, &mediaErr) {
+		return mediaErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
+
+// RetryAt reports whether X turned the request away for its rate limit
+// and when it can be tried again: when the limit resets, or after a full
+// rate-limit window when X did not say.
+func RetryAt(err error) (time.Time, bool) {
+	var postErr *PostAPIError
+	if errors.As(err, &postErr) && postErr.StatusCode == http.StatusTooManyRequests {
+		if postErr.ResetTime.After(time.Now()) {
+			return postErr.ResetTime, true
+		}
+
+		return time.Now().Add(rateLimitWindow), true
+	}
+
+	var mediaErr *MediaAPIError
+	if errors.As(err, &mediaErr) && mediaErr.StatusCode == http.StatusTooManyRequests {
+		return time.Now().Add(rateLimitWindow), true
+	}
+
+	var rateErr *models.RateLimitError
+	if errors.As(err, &rateErr) {
+		if rateErr.Info != nil && rateErr.Info.ResetTime.After(time.Now()) {
+			return rateErr.Info.ResetTime, true
+		}
+
+		return time.Now().Add(time.Duration(rateErr.RetryAfterSecs) * time.Second), true
 	}
 
-	return maxPostLength, userResp, nil
+	return time.Time{}, false
 }
 
-func SendPost(ctx context.Context, text string, accessToken string) (*models.PostResponse, *models.RateLimitInfo, error) {
+func SendPost(ctx context.Context, post *models.Post, accessToken string) (*models.PostResponse, *models.RateLimitInfo, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	postURL := "https://api.twitter.com/2/tweets"
-	postReq := models.Post{Text: text}
 
-	jsonData, err := json.Marshal(postReq)
+	jsonData, err := json.Marshal(post)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error marshaling post request: %w", err)
 	}
@@ -196,8 +274,7 @@ func SendPost(ctx context.Context, text string, accessToken string) (*models.Pos
 	body, _ := io.ReadAll(resp.Body)
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, rateLimitInfo, fmt.Errorf("error posting, status code: %d, response: %s",
-			resp.StatusCode, string(body))
+		return nil, rateLimitInfo, &PostAPIError{StatusCode: resp.StatusCode, Body: string(body), ResetTime: rateLimitInfo.ResetTime}
 	}
 
 	var postResp models.PostResponse
@@ -254,8 +331,7 @@ func SendReplyPost(ctx context.Context, threadPost *models.ReplyPost, accessToke
 	}
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, rateLimitInfo, fmt.Errorf("error posting, status code: %d, response: %s",
-			resp.StatusCode, string(body))
+		return nil, rateLimitInfo, &PostAPIError{StatusCode: resp.StatusCode, Body: string(body), ResetTime: rateLimitInfo.ResetTime}
 	}
 
 	var postResp models.PostResponse
@@ -330,6 +406,376 @@ func GetHomeTimeline(ctx context.Context, userID string, accessToken string) (*m
 	return &timelineResp, rateLimitInfo, nil
 }
 
+// GetUserPosts returns up to 100 of the user's own posts created since the
+// given time, newest first.
+func GetUserPosts(ctx context.Context, userID string, since time.Time, accessToken string) ([]models.Tweet, *models.RateLimitInfo, error) {
+	postsURL := fmt.Sprintf("https://api.twitter.com/2/users/%s/tweets", url.PathEscape(userID))
+	tweetFields := []string{"created_at", "id", "text", "referenced_tweets", "entities"}
+
+	query := url.Values{}
+	query.Set("max_results", "100")
+	query.Set("start_time", since.UTC().Format(time.RFC3339))
+	query.Set("tweet.fields", strings.Join(tweetFields, ","))
+
+	fullURL := fmt.Sprintf("%s?%s", postsURL, query.Encode())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating posts request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error sending posts request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rateLimitInfo, err := extractRateLimitInfo(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error extracting rate limit info: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, rateLimitInfo, &PostAPIError{StatusCode: resp.StatusCode, Body: string(bodyBytes), ResetTime: rateLimitInfo.ResetTime}
+	}
+
+	var postsResp models.TimelineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&postsResp); err != nil {
+		return nil, rateLimitInfo, fmt.Errorf("error decoding posts response: %w", err)
+	}
+
+	return postsResp.Data, rateLimitInfo, nil
+}
+
+func GetPost(ctx context.Context, postID string, accessToken string) (*models.SinglePostResponse, *models.RateLimitInfo, error) {
+	postURL := fmt.Sprintf("https://api.twitter.com/2/tweets/%s", url.PathEscape(postID))
+	userFields := []string{"id", "name", "username", "verified", "verified_type"}
+	tweetFields := []string{"attachments", "author_id", "created_at", "id", "public_metrics", "text", "referenced_tweets", "entities"}
+
+	query := url.Values{}
+	query.Set("tweet.fields", strings.Join(tweetFields, ","))
+	query.Set("user.fields", strings.Join(userFields, ","))
+	query.Set("expansions", "author_id")
+
+	fullURL := fmt.Sprintf("%s?%s", postURL, query.Encode())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating post request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error sending post request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rateLimitInfo, err := extractRateLimitInfo(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error extracting rate limit info: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, rateLimitInfo, fmt.Errorf("error fetching post, status code: %d, response: %s",
+			resp.StatusCode, string(bodyBytes))
+	}
+
+	var postResp models.SinglePostResponse
+	if err := json.NewDecoder(resp.Body).Decode(&postResp); err != nil {
+		return nil, rateLimitInfo, fmt.Errorf("error decoding post response: %w", err)
+	}
+
+	if postResp.Data.ID == "" {
+		return nil, rateLimitInfo, fmt.Errorf("post %s not found", postID)
+	}
+
+	return &postResp, rateLimitInfo, nil
+}
+
+// MaxUsersPerLookup is how many usernames LookupUsers takes at once.
+const MaxUsersPerLookup = 100
+
+// LookupUsers resolves usernames in one request. Usernames that do not
+// resolve come back in the response's Errors rather than as an error.
+func LookupUsers(ctx context.Context, usernames []string, accessToken string) (*models.UsersResponse, *models.RateLimitInfo, error) {
+	if len(usernames) > MaxUsersPerLookup {
+		return nil, nil, fmt.Errorf("at most %d usernames can be looked up at once, got %d", MaxUsersPerLookup, len(usernames))
+	}
+
+	userFields := []string{"id", "name", "username", "verified", "verified_type"}
+
+	query := url.Values{}
+	query.Set("usernames", strings.Join(usernames, ","))
+	query.Set("user.fields", strings.Join(userFields, ","))
+
+	fullURL := fmt.Sprintf("https://api.twitter.com/2/users/by?%s", query.Encode())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating users request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error sending users request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rateLimitInfo, err := extractRateLimitInfo(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error extracting rate limit info: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, rateLimitInfo, &PostAPIError{StatusCode: resp.StatusCode, Body: string(bodyBytes), ResetTime: rateLimitInfo.ResetTime}
+	}
+
+	var usersResp models.UsersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&usersResp); err != nil {
+		return nil, rateLimitInfo, fmt.Errorf("error decoding users response: %w", err)
+	}
+
+	return &usersResp, rateLimitInfo, nil
+}
+
+var communityFields = []string{"id", "name", "description", "access", "join_policy", "member_count", "created_at"}
+
+func GetCommunity(ctx context.Context, communityID string, accessToken string) (*models.Community, *models.RateLimitInfo, error) {
+	query := url.Values{}
+	query.Set("community.fields", strings.Join(communityFields, ","))
+
+	fullURL := fmt.Sprintf("https://api.twitter.com/2/communities/%s?%s", url.PathEscape(communityID), query.Encode())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating community request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error sending community request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rateLimitInfo, err := extractRateLimitInfo(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error extracting rate limit info: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, rateLimitInfo, &PostAPIError{StatusCode: resp.StatusCode, Body: string(bodyBytes), ResetTime: rateLimitInfo.ResetTime}
+	}
+
+	var communityResp models.CommunityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&communityResp); err != nil {
+		return nil, rateLimitInfo, fmt.Errorf("error decoding community response: %w", err)
+	}
+
+	if communityResp.Data.ID == "" {
+		if len(communityResp.Errors) > 0 {
+			return nil, rateLimitInfo, fmt.Errorf("community %s: %s", communityID, communityResp.Errors[0].Detail)
+		}
+
+		return nil, rateLimitInfo, fmt.Errorf("community %s not found", communityID)
+	}
+
+	return &communityResp.Data, rateLimitInfo, nil
+}
+
+// SearchCommunities finds communities whose name matches query. X returns
+// between 10 and 100 results per request.
+func SearchCommunities(ctx context.Context, searchQuery string, maxResults int, accessToken string) ([]models.Community, *models.RateLimitInfo, error) {
+	query := url.Values{}
+	query.Set("query", searchQuery)
+	query.Set("max_results", strconv.Itoa(min(max(maxResults, 10), 100)))
+	query.Set("community.fields", strings.Join(communityFields, ","))
+
+	fullURL := fmt.Sprintf("https://api.twitter.com/2/communities/search?%s", query.Encode())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating community search request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error sending community search request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rateLimitInfo, err := extractRateLimitInfo(resp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error extracting rate limit info: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, rateLimitInfo, &PostAPIError{StatusCode: resp.StatusCode, Body: string(bodyBytes), ResetTime: rateLimitInfo.ResetTime}
+	}
+
+	var communitiesResp models.CommunitiesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&communitiesResp); err != nil {
+		return nil, rateLimitInfo, fmt.Errorf("error decoding community search response: %w", err)
+	}
+
+	return communitiesResp.Data, rateLimitInfo, nil
+}
+
+func DeletePost(ctx context.Context, postID string, accessToken string) (*models.RateLimitInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	postURL := fmt.Sprintf("https://api.twitter.com/2/tweets/%s", url.PathEscape(postID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, postURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating delete request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending delete request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	rateLimitInfo, err := extractRateLimitInfo(resp)
+	if err != nil {
+		return nil, fmt.Errorf("error extracting rate limit info: %w", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return rateLimitInfo, fmt.Errorf("error deleting post, status code: %d, response: %s",
+			resp.StatusCode, string(body))
+	}
+
+	var deleteResp models.DeleteResponse
+	if err := json.Unmarshal(body, &deleteResp); err != nil {
+		return rateLimitInfo, fmt.Errorf("error unmarshaling delete response: %w", err)
+	}
+
+	if !deleteResp.Data.Deleted {
+		return rateLimitInfo, fmt.Errorf("post %s was not deleted", postID)
+	}
+
+	return rateLimitInfo, nil
+}
+
 func extractRateLimitInfo(resp *http.Response) (*models.RateLimitInfo, error) {
 	remainingStr := resp.Header.Get("X-Rate-Limit-Remaining")
 	limitStr := resp.Header.Get("X-Rate-Limit-Limit")
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"x-dev/internal/models"
+)
+
+const (
+	mediaUploadURL   = "https://api.twitter.com/2/media/upload"
+	mediaMetadataURL = "https://api.twitter.com/2/media/metadata"
+)
+
+type MediaAPIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *MediaAPIError) Error() string {
+	return fmt.Sprintf("media request failed, status code: %d, response: %s", e.StatusCode, e.Body)
+}
+
+func UploadMedia(ctx context.Context, attachment *models.MediaAttachment, accessToken string) (*models.MediaUploadResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	file, err := os.Open(attachment.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening media file: %w", err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading media file: %w", err)
+	}
+
+	fields := map[string]string{"media_category": attachment.Category}
+
+	return postMediaForm(ctx, fields, content, accessToken)
+}
+
+func InitChunkedUpload(ctx context.Context, attachment *models.MediaAttachment, accessToken string) (*models.MediaUploadResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	fields := map[string]string{
+		"command":        "INIT",
+		"total_bytes":    strconv.FormatInt(attachment.Size, 10),
+		"media_type":     attachment.MediaType,
+		"media_category": attachment.Category,
+	}
+
+	return postMediaForm(ctx, fields, nil, accessToken)
+}
+
+func AppendChunk(ctx context.Context, mediaID string, segmentIndex int, chunk []byte, accessToken string) error {
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	defer cancel()
+
+	fields := map[string]string{
+		"command":       "APPEND",
+		"media_id":      mediaID,
+		"segment_index": strconv.Itoa(segmentIndex),
+	}
+
+	_, err := postMediaForm(ctx, fields, chunk, accessToken)
+
+	return err
+}
+
+func FinalizeChunkedUpload(ctx context.Context, mediaID string, accessToken string) (*models.MediaUploadResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	fields := map[string]string{
+		"command":  "FINALIZE",
+		"media_id": mediaID,
+	}
+
+	return postMediaForm(ctx, fields, nil, accessToken)
+}
+
+func MediaUploadStatus(ctx context.Context, mediaID string, accessToken string) (*models.MediaUploadResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := url.Values{}
+	query.Set("command", "STATUS")
+	query.Set("media_id", mediaID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaUploadURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating media status request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	return doMediaRequest(req)
+}
+
+func SetMediaAltText(ctx context.Context, mediaID string, altText string, accessToken string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	metadataReq := models.MediaMetadataRequest{ID: mediaID}
+	metadataReq.Metadata.AltText.Text = altText
+
+	jsonData, err := json.Marshal(metadataReq)
+	if err != nil {
+		return fmt.Errorf("error marshaling media metadata request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mediaMetadataURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("error creating media metadata request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = doMediaRequest(req)
+
+	return err
+}
+
+func postMediaForm(ctx context.Context, fields map[string]string, media []byte, accessToken string) (*models.MediaUploadResponse, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, fmt.Errorf("error writing form field %s: %w", name, err)
+		}
+	}
+
+	if media != nil {
+		part, err := writer.CreateFormFile("media", "blob")
+		if err != nil {
+			return nil, fmt.Errorf("error creating media form field: %w", err)
+		}
+
+		if _, err := part.Write(media); err != nil {
+			return nil, fmt.Errorf("error writing media form field: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error finalizing media upload body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, mediaUploadURL, &body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating media upload request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return doMediaRequest(req)
+}
+
+func doMediaRequest(req *http.Request) (*models.MediaUploadResponse, error) {
+	client := &http.Client{
+		Timeout: 120 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending media request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &MediaAPIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var uploadResp models.MediaUploadResponse
+	if len(bytes.TrimSpace(respBody)) == 0 {
+		return &uploadResp, nil
+	}
+
+	if err := json.Unmarshal(respBody, &uploadResp); err != nil {
+		return nil, fmt.Errorf("error unmarshaling media response: %w", err)
+	}
+
+	return &uploadResp, nil
+}
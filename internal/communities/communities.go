@@ -0,0 +1,103 @@
+package communities
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"x-dev/internal/api"
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+// communitiesFile keeps the details of communities looked up before, so
+// names can be shown when X is unreachable and in the history.
+const communitiesFile = "communities.json"
+
+var cacheMu sync.Mutex
+
+// Joined looks up the communities with the given IDs. A community that
+// cannot be looked up is still returned, with the details seen last time
+// or only its ID, and the first lookup error is returned alongside.
+func Joined(ctx context.Context, ids []string, accessToken string) ([]models.Community, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cached, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		joined   []models.Community
+		firstErr error
+	)
+
+	for _, id := range ids {
+		community, _, err := api.GetCommunity(ctx, id, accessToken)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			if known, ok := cached[id]; ok {
+				joined = append(joined, known)
+			} else {
+				joined = append(joined, models.Community{ID: id})
+			}
+
+			continue
+		}
+
+		cached[id] = *community
+		joined = append(joined, *community)
+	}
+
+	if err := save(cached); err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	return joined, firstErr
+}
+
+// Cached returns the details of a community seen before.
+func Cached(id string) (models.Community, bool) {
+	cached, err := load()
+	if err != nil {
+		return models.Community{}, false
+	}
+
+	community, ok := cached[id]
+
+	return community, ok
+}
+
+// Label names a community for menus and the preview, falling back to its
+// ID when the name is not known.
+func Label(community models.Community) string {
+	if community.Name == "" {
+		return "community " + community.ID
+	}
+
+	return fmt.Sprintf("%s (%s)", community.Name, community.ID)
+}
+
+func load() (map[string]models.Community, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	cached := map[string]models.Community{}
+	if _, err := store.Load(communitiesFile, &cached); err != nil {
+		return nil, err
+	}
+
+	return cached, nil
+}
+
+func save(cached map[string]models.Community) error {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	return store.Save(communitiesFile, cached)
+}
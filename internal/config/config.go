@@ -44,6 +44,10 @@ func (ec *EditorConfig) ChooseEditor() (*Editor, error) {
 }
 
 func (e Editor) OpenEditor(ctx context.Context) (string, error) {
+	return e.EditContent(ctx, "")
+}
+
+func (e Editor) EditContent(ctx context.Context, initial string) (string, error) {
 	timestamp := time.Now().Format("20060102_150405")
 	tmpfile, err := os.CreateTemp("", fmt.Sprintf("posteditor_%s_*.txt", timestamp))
 	if err != nil {
@@ -54,6 +58,10 @@ func (e Editor) OpenEditor(ctx context.Context) (string, error) {
 	defer os.Remove(tmpfileName)
 	defer tmpfile.Close()
 
+	if _, err := tmpfile.WriteString(initial); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
 	editorPath, err := exec.LookPath(e.Path)
 	if err != nil {
 		return "", fmt.Errorf("editor not found: %s", e.Path)
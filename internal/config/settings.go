@@ -0,0 +1,454 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/postref"
+	"x-dev/internal/store"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	settingsFile = "config.json"
+
+	DefaultProfileName = "default"
+
+	defaultChunkSize       = "4MiB"
+	defaultThreadSeparator = "---"
+	defaultUploadWorkers   = 3
+	minChunkSize           = 64 * 1024
+	maxChunkSize           = 5 * 1024 * 1024
+	maxUndoSeconds         = 60
+
+	defaultSchedulerInterval = 30 * time.Second
+	defaultSchedulerGrace    = 15 * time.Minute
+
+	defaultDuplicateThreshold = 0.9
+	defaultDuplicateWindow    = 7 * 24 * time.Hour
+)
+
+const (
+	MissedPolicyPost = "post"
+	MissedPolicySkip = "skip"
+)
+
+const (
+	LintSeverityOff     = "off"
+	LintSeverityWarning = "warning"
+	LintSeverityError   = "error"
+)
+
+const (
+	DuplicateCheckOff     = "off"
+	DuplicateCheckHistory = "history"
+	DuplicateCheckAccount = "account"
+)
+
+const (
+	LinkModeAppend   = "append"
+	LinkModeOverride = "override"
+)
+
+// SpellingOff as a profile's spelling language turns off a global one.
+const SpellingOff = "off"
+
+type Settings struct {
+	Media          MediaSettings              `json:"media"`
+	Accessibility  AccessibilitySettings      `json:"accessibility"`
+	Compose        ComposeSettings            `json:"compose"`
+	Scheduler      SchedulerSettings          `json:"scheduler"`
+	Duplicates     DuplicateSettings          `json:"duplicates"`
+	Spelling       SpellingSettings           `json:"spelling"`
+	DefaultProfile string                     `json:"default_profile,omitempty"`
+	Profiles       map[string]ProfileSettings `json:"profiles,omitempty"`
+}
+
+// ProfileSettings holds the defaults for one account. Profiles are chosen
+// with --profile, falling back to default_profile and then "default".
+type ProfileSettings struct {
+	Name          string                      `json:"-"`
+	ReplySettings string                      `json:"reply_settings,omitempty"`
+	Lint          map[string]LintRuleSettings `json:"lint,omitempty"`
+	Spelling      ProfileSpelling             `json:"spelling"`
+	Links         LinkSettings                `json:"links"`
+	// Communities are the IDs of the X Communities the account belongs to.
+	// X has no API listing them, so they are configured here.
+	Communities []string `json:"communities,omitempty"`
+	// Vars are template variables for this profile, so a post sent from
+	// several profiles can differ in details such as a product name.
+	Vars     map[string]string `json:"vars,omitempty"`
+	Mastodon MastodonSettings  `json:"mastodon"`
+}
+
+// MastodonSettings links a profile to a Mastodon account on Instance, such
+// as https://mastodon.social. Enabled turns the Mastodon copy on for new
+// posts by default; Visibility and ContentWarning are its defaults.
+type MastodonSettings struct {
+	Instance       string `json:"instance,omitempty"`
+	Enabled        bool   `json:"enabled,omitempty"`
+	Visibility     string `json:"visibility,omitempty"`
+	ContentWarning string `json:"content_warning,omitempty"`
+}
+
+// LinkSettings rewrites the links in a profile's posts before the preview.
+// Rules add UTM parameters to links on their domains; links on other
+// domains lose their tracking parameters when StripTracking is set.
+type LinkSettings struct {
+	Rules         []LinkRule `json:"rules,omitempty"`
+	StripTracking bool       `json:"strip_tracking,omitempty"`
+	Canonicalize  bool       `json:"canonicalize,omitempty"`
+}
+
+// LinkRule applies to links on Domains and their subdomains. UTM keys may
+// leave out the utm_ prefix. Mode "append" only adds missing parameters,
+// "override" also replaces the ones already in the link.
+type LinkRule struct {
+	Domains []string          `json:"domains"`
+	UTM     map[string]string `json:"utm"`
+	Mode    string            `json:"mode,omitempty"`
+}
+
+// ProfileSpelling picks the dictionary for a profile's posts. Words and the
+// lines of WordsFile make up the personal word list.
+type ProfileSpelling struct {
+	Language  string   `json:"language,omitempty"`
+	Words     []string `json:"words,omitempty"`
+	WordsFile string   `json:"words_file,omitempty"`
+}
+
+// LintRuleSettings turns one lint rule on or off for a profile. Max and
+// Words are only read by the rules that take a limit or a word list.
+type LintRuleSettings struct {
+	Severity string   `json:"severity,omitempty"`
+	Max      int      `json:"max,omitempty"`
+	Words    []string `json:"words,omitempty"`
+}
+
+type MediaSettings struct {
+	ChunkSize     string `json:"chunk_size,omitempty"`
+	UploadWorkers int    `json:"upload_workers,omitempty"`
+}
+
+type ComposeSettings struct {
+	ThreadSeparator string `json:"thread_separator,omitempty"`
+	UndoSeconds     int    `json:"undo_seconds,omitempty"`
+}
+
+// SchedulerSettings controls "scheduler run". Posts that are overdue by
+// more than GracePeriod, for example because the machine was asleep, are
+// handled by MissedPolicy: "post" sends them late, "skip" drops them.
+type SchedulerSettings struct {
+	Interval     string `json:"interval,omitempty"`
+	GracePeriod  string `json:"grace_period,omitempty"`
+	MissedPolicy string `json:"missed_policy,omitempty"`
+}
+
+func (s SchedulerSettings) IntervalDuration() time.Duration {
+	return durationOr(s.Interval, defaultSchedulerInterval)
+}
+
+func (s SchedulerSettings) GraceDuration() time.Duration {
+	return durationOr(s.GracePeriod, defaultSchedulerGrace)
+}
+
+// DuplicateSettings controls the duplicate warning in the post preview.
+// Check "history" compares against the local history, "account" also
+// fetches your recent posts from X. Threshold is the similarity, from 0 to
+// 1, at which a post counts as a near duplicate.
+type DuplicateSettings struct {
+	Check     string  `json:"check,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Window    string  `json:"window,omitempty"`
+}
+
+func (s DuplicateSettings) WindowDuration() time.Duration {
+	return durationOr(s.Window, defaultDuplicateWindow)
+}
+
+// SpellingSettings turns on the spell check in the post preview. Language
+// is the Hunspell dictionary, such as en_US, for profiles that do not set
+// their own; DictionaryPaths are searched before the system folders.
+type SpellingSettings struct {
+	Language        string   `json:"language,omitempty"`
+	DictionaryPaths []string `json:"dictionary_paths,omitempty"`
+}
+
+// SpellingLanguage returns the dictionary to check profile's posts with,
+// or "" when spell checking is off. A profile turns it off with "off".
+func (s *Settings) SpellingLanguage(profile *ProfileSettings) string {
+	switch profile.Spelling.Language {
+	case "":
+		return s.Spelling.Language
+	case SpellingOff:
+		return ""
+	default:
+		return profile.Spelling.Language
+	}
+}
+
+type AccessibilitySettings struct {
+	AltTextPolicy string `json:"alt_text_policy,omitempty"`
+}
+
+func LoadSettings() (*Settings, error) {
+	settings := &Settings{}
+
+	if _, err := store.Load(settingsFile, settings); err != nil {
+		return nil, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	if settings.Media.ChunkSize == "" {
+		settings.Media.ChunkSize = defaultChunkSize
+	}
+
+	if settings.Media.UploadWorkers <= 0 {
+		settings.Media.UploadWorkers = defaultUploadWorkers
+	}
+
+	if settings.Compose.ThreadSeparator == "" {
+		settings.Compose.ThreadSeparator = defaultThreadSeparator
+	}
+
+	if err := ValidateUndoSeconds(settings.Compose.UndoSeconds); err != nil {
+		return nil, err
+	}
+
+	if err := validateScheduler(&settings.Scheduler); err != nil {
+		return nil, err
+	}
+
+	if err := validateDuplicates(&settings.Duplicates); err != nil {
+		return nil, err
+	}
+
+	if settings.Accessibility.AltTextPolicy == "" {
+		settings.Accessibility.AltTextPolicy = models.AltTextPolicyWarn
+	}
+
+	if err := ValidateAltTextPolicy(settings.Accessibility.AltTextPolicy); err != nil {
+		return nil, err
+	}
+
+	for name, profile := range settings.Profiles {
+		replySettings, err := ParseReplySettings(profile.ReplySettings)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		profile.ReplySettings = replySettings
+
+		for rule, ruleSettings := range profile.Lint {
+			if err := ValidateLintSeverity(ruleSettings.Severity); err != nil {
+				return nil, fmt.Errorf("profile %q, lint rule %q: %w", name, rule, err)
+			}
+		}
+
+		if err := validateLinks(&profile.Links); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		if err := validateMastodon(&profile.Mastodon); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		for i, community := range profile.Communities {
+			if profile.Communities[i], err = postref.ParseCommunityID(community); err != nil {
+				return nil, fmt.Errorf("profile %q: %w", name, err)
+			}
+		}
+
+		settings.Profiles[name] = profile
+	}
+
+	return settings, nil
+}
+
+func (s *Settings) Profile(name string) (*ProfileSettings, error) {
+	if name == "" {
+		name = s.DefaultProfile
+	}
+
+	if name == "" {
+		name = DefaultProfileName
+	}
+
+	profile, ok := s.Profiles[name]
+	if !ok && name != DefaultProfileName {
+		return nil, fmt.Errorf("unknown profile %q, add it under \"profiles\" in %s", name, settingsFile)
+	}
+
+	// The implicit default profile was not filled in by LoadSettings.
+	if !ok {
+		profile.Mastodon.Visibility = models.MastodonVisibilityPublic
+	}
+
+	profile.Name = name
+
+	return &profile, nil
+}
+
+func ValidateAltTextPolicy(policy string) error {
+	switch policy {
+	case models.AltTextPolicyOff, models.AltTextPolicyWarn, models.AltTextPolicyRequire:
+		return nil
+	default:
+		return fmt.Errorf("invalid alt text policy %q, expected off, warn or require", policy)
+	}
+}
+
+// ValidateLintSeverity accepts the severities a lint rule can be set to.
+// An empty severity keeps the rule's default.
+func ValidateLintSeverity(severity string) error {
+	switch severity {
+	case "", LintSeverityOff, LintSeverityWarning, LintSeverityError:
+		return nil
+	default:
+		return fmt.Errorf("invalid severity %q, expected off, warning or error", severity)
+	}
+}
+
+// ParseReplySettings returns the reply_settings value sent to the API. Posts
+// open to everyone leave the field out, so "everyone" becomes "".
+func ParseReplySettings(value string) (string, error) {
+	switch value {
+	case "", models.ReplySettingsEveryone:
+		return "", nil
+	case models.ReplySettingsFollowing, models.ReplySettingsMentioned:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid reply settings %q, expected everyone, following or mentionedUsers", value)
+	}
+}
+
+func validateScheduler(scheduler *SchedulerSettings) error {
+	if scheduler.MissedPolicy == "" {
+		scheduler.MissedPolicy = MissedPolicyPost
+	}
+
+	if scheduler.MissedPolicy != MissedPolicyPost && scheduler.MissedPolicy != MissedPolicySkip {
+		return fmt.Errorf("invalid scheduler missed_policy %q, expected post or skip", scheduler.MissedPolicy)
+	}
+
+	for name, value := range map[string]string{"interval": scheduler.Interval, "grace_period": scheduler.GracePeriod} {
+		if value == "" {
+			continue
+		}
+
+		if duration, err := time.ParseDuration(value); err != nil || duration <= 0 {
+			return fmt.Errorf("invalid scheduler %s %q, expected a duration such as 30s or 15m", name, value)
+		}
+	}
+
+	return nil
+}
+
+func validateDuplicates(duplicates *DuplicateSettings) error {
+	switch duplicates.Check {
+	case "":
+		duplicates.Check = DuplicateCheckHistory
+	case DuplicateCheckOff, DuplicateCheckHistory, DuplicateCheckAccount:
+	default:
+		return fmt.Errorf("invalid duplicates check %q, expected off, history or account", duplicates.Check)
+	}
+
+	if duplicates.Threshold == 0 {
+		duplicates.Threshold = defaultDuplicateThreshold
+	}
+
+	if duplicates.Threshold < 0 || duplicates.Threshold > 1 {
+		return fmt.Errorf("invalid duplicates threshold %v, expected a value between 0 and 1", duplicates.Threshold)
+	}
+
+	if duplicates.Window != "" {
+		if duration, err := time.ParseDuration(duplicates.Window); err != nil || duration <= 0 {
+			return fmt.Errorf("invalid duplicates window %q, expected a duration such as 24h or 168h", duplicates.Window)
+		}
+	}
+
+	return nil
+}
+
+func validateLinks(links *LinkSettings) error {
+	for i := range links.Rules {
+		rule := &links.Rules[i]
+
+		if len(rule.Domains) == 0 {
+			return fmt.Errorf("link rule %d has no domains", i+1)
+		}
+
+		if len(rule.UTM) == 0 {
+			return fmt.Errorf("link rule %d has no utm parameters", i+1)
+		}
+
+		switch rule.Mode {
+		case "":
+			rule.Mode = LinkModeAppend
+		case LinkModeAppend, LinkModeOverride:
+		default:
+			return fmt.Errorf("invalid link rule mode %q, expected append or override", rule.Mode)
+		}
+	}
+
+	return nil
+}
+
+func durationOr(value string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return fallback
+	}
+
+	return duration
+}
+
+func ValidateUndoSeconds(seconds int) error {
+	if seconds < 0 || seconds > maxUndoSeconds {
+		return fmt.Errorf("undo window must be between 0 and %d seconds, got %d", maxUndoSeconds, seconds)
+	}
+
+	return nil
+}
+
+func ParseChunkSize(value string) (int64, error) {
+	size, err := humanize.ParseBytes(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chunk size %q: %w", value, err)
+	}
+
+	if size < minChunkSize || size > maxChunkSize {
+		return 0, fmt.Errorf("chunk size %s must be between %s and %s",
+			humanize.IBytes(size), humanize.IBytes(minChunkSize), humanize.IBytes(maxChunkSize))
+	}
+
+	return int64(size), nil
+}
+
+func validateMastodon(mastodon *MastodonSettings) error {
+	if mastodon.Visibility == "" {
+		mastodon.Visibility = models.MastodonVisibilityPublic
+	}
+
+	if err := ValidateMastodonVisibility(mastodon.Visibility); err != nil {
+		return err
+	}
+
+	if mastodon.Enabled && mastodon.Instance == "" {
+		return errors.New("mastodon is enabled but has no instance")
+	}
+
+	return nil
+}
+
+func ValidateMastodonVisibility(visibility string) error {
+	switch visibility {
+	case models.MastodonVisibilityPublic, models.MastodonVisibilityUnlisted,
+		models.MastodonVisibilityPrivate, models.MastodonVisibilityDirect:
+		return nil
+	default:
+		return fmt.Errorf("invalid mastodon visibility %q, use public, unlisted, private or direct", visibility)
+	}
+}
@@ -0,0 +1,184 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+	"x-dev/internal/xauth"
+)
+
+const (
+	tokensFile = "tokens.json"
+
+	// refreshLockFile is held across processes while a login is refreshed,
+	// as X rotates the refresh token and only one of two parallel refreshes
+	// would survive.
+	refreshLockFile = "tokens.lock"
+
+	// refreshLockWait is how long Fresh waits for another process to
+	// finish its refresh; a lock older than that is taken over.
+	refreshLockWait = time.Minute
+
+	// refreshMargin renews access tokens a little before they expire so a
+	// request in flight does not race the expiry.
+	refreshMargin = 2 * time.Minute
+)
+
+var tokensMu sync.Mutex
+
+// Save stores the token of a browser login under profile so background
+// commands such as the scheduler can act for that account later.
+func Save(profile string, token *models.TokenResponse, user models.UserResponse) error {
+	return update(profile, func(stored *models.StoredToken) {
+		stored.UserID = user.Data.ID
+		stored.Username = user.Data.Username
+		stored.Verified = user.Data.Verified
+		applyToken(stored, token)
+	})
+}
+
+func Load(profile string) (*models.StoredToken, error) {
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	tokens, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, ok := tokens[profile]
+	if !ok {
+		return nil, fmt.Errorf("no stored login for profile %q, sign in once with \"x-yapper --profile %s\"", profile, profile)
+	}
+
+	return stored, nil
+}
+
+// Fresh returns a usable token for profile, refreshing and storing it when
+// the access token is about to expire.
+func Fresh(ctx context.Context, profile string, clientID string, clientSecret string) (*models.StoredToken, error) {
+	stored, err := Load(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Until(stored.ExpiresAt) > refreshMargin {
+		return stored, nil
+	}
+
+	lock, err := acquireRefreshLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Release()
+
+	// The scheduler or another session may have refreshed it meanwhile.
+	if stored, err = Load(profile); err != nil {
+		return nil, err
+	}
+
+	if time.Until(stored.ExpiresAt) > refreshMargin {
+		return stored, nil
+	}
+
+	if stored.RefreshToken == "" {
+		return nil, fmt.Errorf("stored login for profile %q cannot be refreshed, sign in again", profile)
+	}
+
+	token, err := xauth.RefreshToken(ctx, clientID, clientSecret, stored.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("error refreshing token for profile %q: %w", profile, err)
+	}
+
+	if err := update(profile, func(stored *models.StoredToken) { applyToken(stored, token) }); err != nil {
+		return nil, err
+	}
+
+	return Load(profile)
+}
+
+// acquireRefreshLock waits until no other process is refreshing a login.
+func acquireRefreshLock(ctx context.Context) (*store.Lock, error) {
+	deadline := time.Now().Add(refreshLockWait)
+
+	for {
+		lock, err := store.AcquireLock(refreshLockFile, refreshLockWait)
+		if err == nil {
+			return lock, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("waiting for another login refresh: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// Secrets returns the access and refresh tokens of every stored login, for
+// the secret scanner.
+func Secrets() ([]string, error) {
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	tokens, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []string
+
+	for _, stored := range tokens {
+		secrets = append(secrets, stored.AccessToken, stored.RefreshToken)
+	}
+
+	return secrets, nil
+}
+
+func update(profile string, change func(*models.StoredToken)) error {
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	tokens, err := load()
+	if err != nil {
+		return err
+	}
+
+	stored, ok := tokens[profile]
+	if !ok {
+		stored = &models.StoredToken{}
+		tokens[profile] = stored
+	}
+
+	change(stored)
+
+	return store.Save(tokensFile, tokens)
+}
+
+func applyToken(stored *models.StoredToken, token *models.TokenResponse) {
+	stored.AccessToken = token.AccessToken
+	stored.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	// X only returns a refresh token when offline.access was granted.
+	if token.RefreshToken != "" {
+		stored.RefreshToken = token.RefreshToken
+	}
+}
+
+func load() (map[string]*models.StoredToken, error) {
+	tokens := make(map[string]*models.StoredToken)
+
+	if _, err := store.Load(tokensFile, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to load stored logins: %w", err)
+	}
+
+	return tokens, nil
+}
@@ -0,0 +1,179 @@
+package crosspost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"x-dev/internal/config"
+	"x-dev/internal/history"
+	"x-dev/internal/links"
+	"x-dev/internal/lint"
+	"x-dev/internal/models"
+	"x-dev/internal/templates"
+)
+
+// Target is the post one profile sends, rendered for that profile. Links
+// are the rewrites its link rules made.
+type Target struct {
+	Profile string
+	Post    models.OutgoingPost
+	Links   []links.Change
+}
+
+// Result is how sending to one profile went. OutboxID is set instead of
+// PostID when X was unreachable and the post waits in the outbox.
+type Result struct {
+	Profile  string
+	Username string
+	PostID   string
+	OutboxID string
+	Err      error
+}
+
+// SendFunc sends one target with its profile's login.
+type SendFunc func(ctx context.Context, target Target) Result
+
+// Poster sends one post from several of the configured profiles.
+type Poster struct {
+	Settings *config.Settings
+	Publish  SendFunc
+	// MaxPostLength returns the post length limit of a profile's account,
+	// which the length lint rule checks against.
+	MaxPostLength func(profile string) int
+}
+
+// Profiles returns the names of the configured profiles, sorted.
+func (p *Poster) Profiles() []string {
+	return slices.Sorted(maps.Keys(p.Settings.Profiles))
+}
+
+// Prepare renders out for each profile. The text is a template: {{.name}}
+// takes the profile's vars, or vars, which win over them. The profile's
+// link rules run on the result, and posts without reply settings get the
+// profile's default.
+func (p *Poster) Prepare(profiles []string, out models.OutgoingPost, vars map[string]string) ([]Target, error) {
+	if out.ReplyTo != "" || out.CommunityID != "" {
+		return nil, errors.New("only posts to your timeline can be sent from several profiles, not replies or community posts")
+	}
+
+	now := time.Now()
+
+	var targets []Target
+
+	seen := map[string]bool{}
+
+	for _, name := range profiles {
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+
+		profile, err := p.Settings.Profile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		data := templates.Data{Time: now, Vars: map[string]string{}}
+		maps.Copy(data.Vars, profile.Vars)
+		maps.Copy(data.Vars, vars)
+
+		text, err := templates.Render(out.Text, data)
+		if err != nil {
+			return nil, fmt.Errorf("profile %q: %w", name, err)
+		}
+
+		post := out
+		post.Text, post.MediaPaths, post.AltTexts = text, slices.Clone(out.MediaPaths), slices.Clone(out.AltTexts)
+
+		var changes []links.Change
+		post.Text, changes = links.New(profile.Links).Rewrite(post.Text)
+
+		if post.ReplySettings == "" {
+			post.ReplySettings = profile.ReplySettings
+		}
+
+		targets = append(targets, Target{Profile: name, Post: post, Links: changes})
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("no profiles to post to")
+	}
+
+	return targets, nil
+}
+
+// Lint runs the lint rules of the target's profile on its version of
+// the post.
+func (p *Poster) Lint(target Target, attachments []*models.MediaAttachment) ([]lint.Finding, error) {
+	profile, err := p.Settings.Profile(target.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	linter, err := lint.New(profile.Lint)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", target.Profile, err)
+	}
+
+	return linter.Run(lint.Post{
+		Text:        target.Post.Text,
+		Attachments: attachments,
+		MaxLength:   p.MaxPostLength(target.Profile),
+	}), nil
+}
+
+// Send sends every target at once, one request per profile, and returns
+// the results in the order of targets. A profile whose posting limit X
+// last reported as used up is not tried until the limit resets.
+func (p *Poster) Send(ctx context.Context, targets []Target) []Result {
+	records, _ := history.Load()
+
+	results := make([]Result, len(targets))
+
+	var wGroup sync.WaitGroup
+
+	for i, target := range targets {
+		if limit := history.LastRateLimit(records, target.Profile); limit != nil && limit.Remaining == 0 && time.Now().Before(limit.ResetTime) {
+			results[i] = Result{
+				Profile: target.Profile,
+				Err:     fmt.Errorf("rate limit reached, resets %s", limit.ResetTime.Local().Format("Jan 2 at 15:04")),
+			}
+
+			continue
+		}
+
+		wGroup.Add(1)
+
+		go func() {
+			defer wGroup.Done()
+
+			result := p.Publish(ctx, target)
+			result.Profile = target.Profile
+			results[i] = result
+		}()
+	}
+
+	wGroup.Wait()
+
+	return results
+}
+
+// Failed counts the profiles the post did not reach. Posts waiting in the
+// outbox are not counted as failed.
+func Failed(results []Result) int {
+	failed := 0
+
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,104 @@
+package drafts
+
+import "regexp"
+
+const (
+	DiffEqual = iota
+	DiffDelete
+	DiffInsert
+)
+
+// maxDiffCells caps the size of the comparison table. Beyond it the changed
+// region is reported as one deletion and one insertion.
+const maxDiffCells = 4_000_000
+
+var tokenPattern = regexp.MustCompile(`\s+|[^\s]+`)
+
+type DiffOp struct {
+	Kind int
+	Text string
+}
+
+// Diff compares two versions word by word, keeping whitespace so the result
+// can be printed inline.
+func Diff(before string, after string) []DiffOp {
+	a := tokenPattern.FindAllString(before, -1)
+	b := tokenPattern.FindAllString(after, -1)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []DiffOp
+
+	ops = appendOp(ops, DiffEqual, a[:prefix]...)
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	ops = appendOp(ops, DiffEqual, a[len(a)-suffix:]...)
+
+	return ops
+}
+
+func diffMiddle(a []string, b []string) []DiffOp {
+	var ops []DiffOp
+
+	if len(a)*len(b) > maxDiffCells {
+		ops = appendOp(ops, DiffDelete, a...)
+		return appendOp(ops, DiffInsert, b...)
+	}
+
+	// lengths[i][j] is the longest common subsequence of a[i:] and b[j:].
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = appendOp(ops, DiffEqual, a[i])
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			ops = appendOp(ops, DiffDelete, a[i])
+			i++
+		default:
+			ops = appendOp(ops, DiffInsert, b[j])
+			j++
+		}
+	}
+
+	ops = appendOp(ops, DiffDelete, a[i:]...)
+
+	return appendOp(ops, DiffInsert, b[j:]...)
+}
+
+func appendOp(ops []DiffOp, kind int, tokens ...string) []DiffOp {
+	for _, token := range tokens {
+		if len(ops) > 0 && ops[len(ops)-1].Kind == kind {
+			ops[len(ops)-1].Text += token
+			continue
+		}
+
+		ops = append(ops, DiffOp{Kind: kind, Text: token})
+	}
+
+	return ops
+}
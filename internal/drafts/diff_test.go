@@ -0,0 +1,83 @@
+package drafts
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		before string
+		after  string
+		want   []DiffOp
+	}{
+		{"", "", nil},
+		{"same text", "same text", []DiffOp{{DiffEqual, "same text"}}},
+		{"", "new text", []DiffOp{{DiffInsert, "new text"}}},
+		{"old text", "", []DiffOp{{DiffDelete, "old text"}}},
+		{"hello world", "hello there world", []DiffOp{
+			{DiffEqual, "hello "}, {DiffInsert, "there "}, {DiffEqual, "world"},
+		}},
+		{"the cat sat", "the dog sat", []DiffOp{
+			{DiffEqual, "the "}, {DiffDelete, "cat"}, {DiffInsert, "dog"}, {DiffEqual, " sat"},
+		}},
+		{"a b c d", "a c d e", []DiffOp{
+			{DiffEqual, "a "}, {DiffDelete, "b "}, {DiffEqual, "c d"}, {DiffInsert, " e"},
+		}},
+		{"a b", "a  b", []DiffOp{
+			{DiffEqual, "a"}, {DiffDelete, " "}, {DiffInsert, "  "}, {DiffEqual, "b"},
+		}},
+		{"line one\nline two", "line one\nline 2", []DiffOp{
+			{DiffEqual, "line one\nline "}, {DiffDelete, "two"}, {DiffInsert, "2"},
+		}},
+	}
+
+	for _, test := range tests {
+		if got := Diff(test.before, test.after); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Diff(%q, %q) = %v, want %v", test.before, test.after, got, test.want)
+		}
+	}
+}
+
+// TestDiffRebuilds checks that every diff turns back into both versions,
+// including one too large to compare word by word.
+func TestDiffRebuilds(t *testing.T) {
+	words := func(prefix string, n int) string {
+		var parts []string
+		for i := range n {
+			parts = append(parts, fmt.Sprintf("%s%d", prefix, i))
+		}
+
+		return strings.Join(parts, " ")
+	}
+
+	tests := []struct {
+		before string
+		after  string
+	}{
+		{"a b c", "c b a"},
+		{"  leading and trailing  ", "leading\tand trailing"},
+		{"x y z x y z", "x z y x z"},
+		{"start " + words("a", 1500) + " end", "start " + words("b", 1500) + " end"},
+	}
+
+	for _, test := range tests {
+		var before, after strings.Builder
+
+		for _, op := range Diff(test.before, test.after) {
+			if op.Kind != DiffInsert {
+				before.WriteString(op.Text)
+			}
+
+			if op.Kind != DiffDelete {
+				after.WriteString(op.Text)
+			}
+		}
+
+		if before.String() != test.before || after.String() != test.after {
+			t.Errorf("Diff(%.20q, %.20q) rebuilds %.20q and %.20q", test.before, test.after, before.String(), after.String())
+		}
+	}
+}
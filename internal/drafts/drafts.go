@@ -0,0 +1,154 @@
+package drafts
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+const (
+	draftsFile = "drafts.json"
+
+	// maxAutosaves bounds how many drafts that were never explicitly saved
+	// are kept. The oldest are dropped first.
+	maxAutosaves = 20
+)
+
+var draftsMu sync.Mutex
+
+func New(kind string, targetID string) *models.Draft {
+	return &models.Draft{Kind: kind, TargetID: targetID}
+}
+
+func Latest(draft *models.Draft) string {
+	if draft == nil || len(draft.Versions) == 0 {
+		return ""
+	}
+
+	return draft.Versions[len(draft.Versions)-1].Text
+}
+
+// Record stores content as the newest version of draft, creating the draft
+// on first use. Content identical to the newest version is not stored again.
+func Record(draft *models.Draft, content string) error {
+	if content == "" || content == Latest(draft) {
+		return nil
+	}
+
+	now := time.Now()
+
+	if draft.ID == "" {
+		draft.ID = now.Format("20060102-150405.000")
+		draft.CreatedAt = now
+	}
+
+	draft.Versions = append(draft.Versions, models.DraftVersion{SavedAt: now, Text: content})
+
+	return Save(draft)
+}
+
+func Save(draft *models.Draft) error {
+	draftsMu.Lock()
+	defer draftsMu.Unlock()
+
+	drafts, err := load()
+	if err != nil {
+		return err
+	}
+
+	draft.UpdatedAt = time.Now()
+
+	replaced := false
+
+	for i := range drafts {
+		if drafts[i].ID == draft.ID {
+			drafts[i] = draft
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		drafts = append(drafts, draft)
+	}
+
+	return store.Save(draftsFile, prune(drafts))
+}
+
+func List() ([]*models.Draft, error) {
+	draftsMu.Lock()
+	defer draftsMu.Unlock()
+
+	drafts, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	sortNewestFirst(drafts)
+
+	return drafts, nil
+}
+
+func Remove(id string) error {
+	if id == "" {
+		return nil
+	}
+
+	draftsMu.Lock()
+	defer draftsMu.Unlock()
+
+	drafts, err := load()
+	if err != nil {
+		return err
+	}
+
+	kept := drafts[:0]
+
+	for _, draft := range drafts {
+		if draft.ID != id {
+			kept = append(kept, draft)
+		}
+	}
+
+	return store.Save(draftsFile, kept)
+}
+
+func load() ([]*models.Draft, error) {
+	var drafts []*models.Draft
+
+	if _, err := store.Load(draftsFile, &drafts); err != nil {
+		return nil, fmt.Errorf("failed to load drafts: %w", err)
+	}
+
+	return drafts, nil
+}
+
+func prune(drafts []*models.Draft) []*models.Draft {
+	sortNewestFirst(drafts)
+
+	kept := drafts[:0]
+	autosaves := 0
+
+	for _, draft := range drafts {
+		if !draft.Saved {
+			autosaves++
+
+			if autosaves > maxAutosaves {
+				continue
+			}
+		}
+
+		kept = append(kept, draft)
+	}
+
+	return kept
+}
+
+func sortNewestFirst(drafts []*models.Draft) {
+	sort.SliceStable(drafts, func(i, j int) bool {
+		return drafts[i].UpdatedAt.After(drafts[j].UpdatedAt)
+	})
+}
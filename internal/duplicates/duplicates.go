@@ -0,0 +1,156 @@
+package duplicates
+
+import (
+	"html"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/twittertext"
+)
+
+// Candidate is an earlier post a draft is compared against.
+type Candidate struct {
+	ID   string
+	Text string
+	Time time.Time
+}
+
+type Match struct {
+	Candidate
+	Score float64
+}
+
+// Normalize reduces a post text to what survives the round trip through X:
+// the API returns text HTML-escaped, and each link is cut down to its host
+// and path, so tracking parameters do not matter but a different page does.
+// Posts fetched from X go through TweetText first.
+func Normalize(text string) string {
+	text = html.UnescapeString(text)
+
+	spans := twittertext.ExtractURLs(text)
+	for i := len(spans) - 1; i >= 0; i-- {
+		text = text[:spans[i].Start] + linkKey(text[spans[i].Start:spans[i].End]) + text[spans[i].End:]
+	}
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// linkKey is the host and path of a link, without scheme, "www.", query or
+// trailing slash.
+func linkKey(link string) string {
+	raw := link
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return link
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+
+	return host + strings.TrimSuffix(u.Path, "/")
+}
+
+// linkKeys returns the sorted, distinct links of text.
+func linkKeys(text string) []string {
+	text = html.UnescapeString(text)
+
+	var keys []string
+	for _, span := range twittertext.ExtractURLs(text) {
+		keys = append(keys, linkKey(text[span.Start:span.End]))
+	}
+
+	slices.Sort(keys)
+
+	return slices.Compact(keys)
+}
+
+// TweetText returns the text of a post fetched from X with its t.co links
+// expanded and the link X adds for attached media removed, as it was
+// written. The post must be fetched with the "entities" field.
+func TweetText(post models.Tweet) string {
+	text := post.Text
+	if post.Entities == nil {
+		return text
+	}
+
+	for _, link := range post.Entities.URLs {
+		if link.MediaKey != "" {
+			text = strings.Replace(text, link.URL, "", 1)
+			continue
+		}
+
+		if link.ExpandedURL != "" {
+			text = strings.Replace(text, link.URL, link.ExpandedURL, 1)
+		}
+	}
+
+	return text
+}
+
+// Similarity scores two post texts from 0 to 1 after normalizing them,
+// using the overlap of their character pairs, so small edits such as a
+// fixed typo still score close to 1. Posts that link to different pages
+// score 0, however alike the rest of the text is.
+func Similarity(a string, b string) float64 {
+	if !slices.Equal(linkKeys(a), linkKeys(b)) {
+		return 0
+	}
+
+	a = strings.ToLower(Normalize(a))
+	b = strings.ToLower(Normalize(b))
+
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 2 || len(rb) < 2 {
+		return 0
+	}
+
+	pairs := make(map[[2]rune]int, len(ra))
+	for i := 0; i+1 < len(ra); i++ {
+		pairs[[2]rune{ra[i], ra[i+1]}]++
+	}
+
+	shared := 0
+
+	for i := 0; i+1 < len(rb); i++ {
+		pair := [2]rune{rb[i], rb[i+1]}
+		if pairs[pair] > 0 {
+			pairs[pair]--
+			shared++
+		}
+	}
+
+	return 2 * float64(shared) / float64(len(ra)-1+len(rb)-1)
+}
+
+// Find returns the candidate most similar to text, if any reaches
+// threshold. Texts that are empty after normalization, such as a post of
+// only media, are never reported.
+func Find(text string, candidates []Candidate, threshold float64) (Match, bool) {
+	if Normalize(text) == "" {
+		return Match{}, false
+	}
+
+	var best Match
+
+	for _, candidate := range candidates {
+		if Normalize(candidate.Text) == "" {
+			continue
+		}
+
+		if score := Similarity(text, candidate.Text); score >= threshold && score > best.Score {
+			best = Match{Candidate: candidate, Score: score}
+		}
+	}
+
+	return best, best.Score > 0
+}
@@ -0,0 +1,204 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+const historyFile = "history.jsonl"
+
+var historyMu sync.Mutex
+
+// Filter selects history records; empty fields match everything.
+type Filter struct {
+	Profile string
+	Action  string
+	Search  string
+	Since   time.Time
+}
+
+// Thread is a chain of posts on one profile where each replies to the one
+// before. Head is the newest post still up, the one to reply to.
+type Thread struct {
+	Head  models.HistoryRecord
+	Posts int
+}
+
+// Record appends rec to the history, stamping it with the current time.
+func Record(rec models.HistoryRecord) error {
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	if err := store.Append(historyFile, rec); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+
+	return nil
+}
+
+// ActionFor names what sending a post with these references does.
+func ActionFor(replyTo string, quoteID string) string {
+	switch {
+	case replyTo != "":
+		return models.HistoryActionReply
+	case quoteID != "":
+		return models.HistoryActionQuote
+	default:
+		return models.HistoryActionPost
+	}
+}
+
+// Load returns every record, oldest first. A line that cannot be decoded,
+// such as one cut short by a crash, is skipped.
+func Load() ([]models.HistoryRecord, error) {
+	path, err := store.Path(historyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", historyFile, err)
+	}
+	defer file.Close()
+
+	var records []models.HistoryRecord
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		var rec models.HistoryRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.ID == "" {
+			continue
+		}
+
+		records = append(records, rec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", historyFile, err)
+	}
+
+	return records, nil
+}
+
+func (f Filter) Match(rec models.HistoryRecord) bool {
+	if f.Profile != "" && rec.Profile != f.Profile {
+		return false
+	}
+
+	if f.Action != "" && rec.Action != f.Action {
+		return false
+	}
+
+	if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+		return false
+	}
+
+	return f.Search == "" || strings.Contains(strings.ToLower(rec.Text), strings.ToLower(f.Search))
+}
+
+// LastRateLimit returns the most recent rate-limit state X reported for
+// profile, or nil when the history has none.
+func LastRateLimit(records []models.HistoryRecord, profile string) *models.RateLimitInfo {
+	for i := len(records) - 1; i >= 0; i-- {
+		if rec := records[i]; rec.Profile == profile && rec.RateLimit != nil && !rec.RateLimit.ResetTime.IsZero() {
+			return rec.RateLimit
+		}
+	}
+
+	return nil
+}
+
+// Find returns the record that created the post with the given ID.
+func Find(records []models.HistoryRecord, id string) (models.HistoryRecord, bool) {
+	for _, rec := range records {
+		if rec.ID == id && rec.Action != models.HistoryActionDelete {
+			return rec, true
+		}
+	}
+
+	return models.HistoryRecord{}, false
+}
+
+// Threads groups the posts of profile into reply chains, the most recently
+// extended first. A deleted post still links its replies to the chain but is
+// never a head.
+func Threads(records []models.HistoryRecord, profile string) []Thread {
+	deleted := map[string]bool{}
+
+	for _, rec := range records {
+		if rec.Action == models.HistoryActionDelete {
+			deleted[rec.ID] = true
+		}
+	}
+
+	rootOf := map[string]string{}
+	byRoot := map[string]*Thread{}
+
+	var roots []string
+
+	for _, rec := range records {
+		if rec.Action == models.HistoryActionDelete || rec.Profile != profile {
+			continue
+		}
+
+		root, ok := rootOf[rec.ReplyTo]
+		if rec.ReplyTo == "" || !ok {
+			root = rec.ID
+		}
+
+		rootOf[rec.ID] = root
+
+		t, ok := byRoot[root]
+		if !ok {
+			t = &Thread{}
+			byRoot[root] = t
+			roots = append(roots, root)
+		}
+
+		if deleted[rec.ID] {
+			continue
+		}
+
+		t.Head = rec
+		t.Posts++
+	}
+
+	threads := make([]Thread, 0, len(roots))
+
+	for _, root := range roots {
+		if t := byRoot[root]; t.Posts > 0 {
+			threads = append(threads, *t)
+		}
+	}
+
+	sort.SliceStable(threads, func(i, j int) bool {
+		return threads[i].Head.Time.After(threads[j].Head.Time)
+	})
+
+	return threads
+}
@@ -0,0 +1,259 @@
+package links
+
+import (
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"x-dev/internal/config"
+	"x-dev/internal/twittertext"
+)
+
+// trackingParameters are stripped from links on domains without a rule.
+// Keys starting with utm_ are stripped as well.
+var trackingParameters = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "gbraid": true, "wbraid": true,
+	"msclkid": true, "yclid": true, "twclid": true, "ttclid": true, "li_fat_id": true,
+	"igshid": true, "mc_cid": true, "mc_eid": true, "_hsenc": true, "_hsmi": true,
+	"mkt_tok": true, "oly_anon_id": true, "oly_enc_id": true, "vero_id": true,
+	"ref_src": true, "ref_url": true, "s_cid": true,
+}
+
+// utmOrder is the order UTM parameters are added in, the usual one.
+var utmOrder = []string{"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content"}
+
+// Change is one link the rules rewrote.
+type Change struct {
+	From string
+	To   string
+}
+
+// Rewriter applies a profile's link rules. A nil Rewriter changes nothing.
+type Rewriter struct {
+	settings config.LinkSettings
+}
+
+// New returns the rewriter for settings, or nil when they do nothing.
+func New(settings config.LinkSettings) *Rewriter {
+	if len(settings.Rules) == 0 && !settings.StripTracking && !settings.Canonicalize {
+		return nil
+	}
+
+	return &Rewriter{settings: settings}
+}
+
+// Rewrite applies the rules to every link in text. Rewriting is
+// idempotent, so it can run again on text it already rewrote.
+func (r *Rewriter) Rewrite(text string) (string, []Change) {
+	if r == nil {
+		return text, nil
+	}
+
+	var changes []Change
+
+	spans := twittertext.ExtractURLs(text)
+
+	for i := len(spans) - 1; i >= 0; i-- {
+		span := spans[i]
+
+		rewritten := r.rewriteURL(span.Text)
+		if rewritten == span.Text {
+			continue
+		}
+
+		text = text[:span.Start] + rewritten + text[span.End:]
+		changes = append([]Change{{From: span.Text, To: rewritten}}, changes...)
+	}
+
+	return text, changes
+}
+
+func (r *Rewriter) rewriteURL(raw string) string {
+	// Bare domains stay bare, X links them just the same.
+	bare := !strings.Contains(raw, "://")
+
+	parsed := raw
+	if bare {
+		parsed = "https://" + raw
+	}
+
+	u, err := url.Parse(parsed)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	changed := false
+
+	if r.settings.Canonicalize {
+		changed = canonicalize(u)
+	}
+
+	params := splitQuery(u.RawQuery)
+
+	if rule := r.ruleFor(u.Hostname()); rule != nil {
+		params = applyUTM(params, rule)
+	} else if r.settings.StripTracking {
+		params = stripTracking(params)
+	}
+
+	if query := joinQuery(params); query != u.RawQuery {
+		u.RawQuery = query
+		changed = true
+	}
+
+	if !changed {
+		return raw
+	}
+
+	rewritten := u.String()
+	if bare {
+		rewritten = strings.TrimPrefix(rewritten, "https://")
+	}
+
+	return rewritten
+}
+
+// ruleFor returns the first rule for host, matching a rule's domains and
+// their subdomains.
+func (r *Rewriter) ruleFor(host string) *config.LinkRule {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for i, rule := range r.settings.Rules {
+		for _, domain := range rule.Domains {
+			domain = strings.TrimPrefix(strings.ToLower(domain), "*.")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return &r.settings.Rules[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// canonicalize lower-cases the scheme and host and drops default ports,
+// empty queries and empty fragments. It reports whether u changed.
+func canonicalize(u *url.URL) bool {
+	before := u.String()
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.TrimSuffix(strings.ToLower(u.Host), ".")
+
+	if port := u.Port(); (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+
+	u.ForceQuery = false
+
+	if u.Fragment == "" {
+		u.RawFragment = ""
+	}
+
+	return u.String() != before
+}
+
+// param is one key=value pair of a query, kept as written so rewriting
+// does not re-encode or reorder what it leaves alone.
+type param struct {
+	key string
+	raw string
+}
+
+func splitQuery(rawQuery string) []param {
+	var params []param
+
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		params = append(params, param{key: key, raw: pair})
+	}
+
+	return params
+}
+
+func joinQuery(params []param) string {
+	pairs := make([]string, len(params))
+	for i, p := range params {
+		pairs[i] = p.raw
+	}
+
+	return strings.Join(pairs, "&")
+}
+
+func applyUTM(params []param, rule *config.LinkRule) []param {
+	values := map[string]string{}
+	for key, value := range rule.UTM {
+		if !strings.HasPrefix(key, "utm_") {
+			key = "utm_" + key
+		}
+
+		values[key] = value
+	}
+
+	present := map[string]bool{}
+
+	for i, p := range params {
+		value, ok := values[p.key]
+		if !ok {
+			continue
+		}
+
+		present[p.key] = true
+
+		if rule.Mode == config.LinkModeOverride {
+			params[i].raw = p.key + "=" + url.QueryEscape(value)
+		}
+	}
+
+	for _, key := range orderedKeys(values) {
+		if !present[key] {
+			params = append(params, param{key: key, raw: key + "=" + url.QueryEscape(values[key])})
+		}
+	}
+
+	return params
+}
+
+// orderedKeys returns the standard UTM keys first, in their usual order,
+// then any others alphabetically.
+func orderedKeys(values map[string]string) []string {
+	var keys, others []string
+
+	for _, key := range utmOrder {
+		if _, ok := values[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	for key := range values {
+		if !slices.Contains(utmOrder, key) {
+			others = append(others, key)
+		}
+	}
+
+	sort.Strings(others)
+
+	return append(keys, others...)
+}
+
+func stripTracking(params []param) []param {
+	var kept []param
+
+	for _, p := range params {
+		key := strings.ToLower(p.key)
+		if strings.HasPrefix(key, "utm_") || trackingParameters[key] {
+			continue
+		}
+
+		kept = append(kept, p)
+	}
+
+	return kept
+}
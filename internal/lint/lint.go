@@ -0,0 +1,120 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"x-dev/internal/config"
+	"x-dev/internal/models"
+)
+
+// Post is what the rules look at: the text after editing plus what the
+// preview knows about it.
+type Post struct {
+	Text        string
+	Attachments []*models.MediaAttachment
+	MaxLength   int
+	Reply       bool
+}
+
+// Rule checks one aspect of a post. Check returns one message per problem;
+// it gets the profile's settings for the rule, such as a limit or a word
+// list.
+type Rule struct {
+	Name     string
+	Severity string
+	Check    func(post Post, settings config.LintRuleSettings) []string
+}
+
+type Finding struct {
+	Rule     string
+	Severity string
+	Message  string
+}
+
+type Linter struct {
+	rules    []Rule
+	settings map[string]config.LintRuleSettings
+}
+
+var registry = builtinRules()
+
+// Register adds a rule to the ones profiles can turn on. A rule with the
+// name of an existing one replaces it.
+func Register(rule Rule) {
+	registry[rule.Name] = rule
+}
+
+// Rules returns the names of the registered rules, sorted.
+func Rules() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// New sets up the rules for a profile. Rules the profile does not mention
+// run with their default severity.
+func New(settings map[string]config.LintRuleSettings) (*Linter, error) {
+	for name := range settings {
+		if _, ok := registry[name]; !ok {
+			return nil, fmt.Errorf("unknown lint rule %q, expected one of %s", name, strings.Join(Rules(), ", "))
+		}
+	}
+
+	linter := &Linter{settings: settings}
+
+	for _, name := range Rules() {
+		linter.rules = append(linter.rules, registry[name])
+	}
+
+	return linter, nil
+}
+
+// Run checks post against every enabled rule, errors first. A nil Linter
+// finds nothing.
+func (l *Linter) Run(post Post) []Finding {
+	if l == nil {
+		return nil
+	}
+
+	var findings []Finding
+
+	for _, rule := range l.rules {
+		settings := l.settings[rule.Name]
+
+		severity := settings.Severity
+		if severity == "" {
+			severity = rule.Severity
+		}
+
+		if severity == config.LintSeverityOff {
+			continue
+		}
+
+		for _, message := range rule.Check(post, settings) {
+			findings = append(findings, Finding{Rule: rule.Name, Severity: severity, Message: message})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity == config.LintSeverityError && findings[j].Severity != config.LintSeverityError
+	})
+
+	return findings
+}
+
+func HasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == config.LintSeverityError {
+			return true
+		}
+	}
+
+	return false
+}
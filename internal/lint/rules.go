@@ -0,0 +1,192 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"x-dev/internal/config"
+	"x-dev/internal/media"
+	"x-dev/internal/twittertext"
+)
+
+const defaultMaxHashtags = 2
+
+var (
+	hashtagPattern        = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])[#＃]([\p{L}\p{M}\p{N}_]*[\p{L}\p{M}][\p{L}\p{M}\p{N}_]*)`)
+	leadingMentionPattern = regexp.MustCompile(`^[@＠]([A-Za-z0-9_]{1,15})`)
+)
+
+// pairedQuotes are the opening and closing quote marks checked for balance.
+// Single quotes are left out since they double as apostrophes.
+var pairedQuotes = [][2]rune{{'“', '”'}, {'«', '»'}, {'「', '」'}}
+
+func builtinRules() map[string]Rule {
+	rules := []Rule{
+		{Name: "length", Severity: config.LintSeverityError, Check: checkLength},
+		{Name: "hashtags", Severity: config.LintSeverityWarning, Check: checkHashtags},
+		{Name: "banned-words", Severity: config.LintSeverityError, Check: checkBannedWords},
+		{Name: "leading-mention", Severity: config.LintSeverityWarning, Check: checkLeadingMention},
+		{Name: "trailing-whitespace", Severity: config.LintSeverityWarning, Check: checkTrailingWhitespace},
+		{Name: "unbalanced-quotes", Severity: config.LintSeverityWarning, Check: checkQuotes},
+		// Off by default, accessibility.alt_text_policy already covers it;
+		// a profile can turn it on to be stricter than the global policy.
+		{Name: "alt-text", Severity: config.LintSeverityOff, Check: checkAltText},
+	}
+
+	registry := make(map[string]Rule, len(rules))
+	for _, rule := range rules {
+		registry[rule.Name] = rule
+	}
+
+	return registry
+}
+
+func checkLength(post Post, settings config.LintRuleSettings) []string {
+	limit := post.MaxLength
+	if settings.Max > 0 && (limit == 0 || settings.Max < limit) {
+		limit = settings.Max
+	}
+
+	if limit == 0 {
+		return nil
+	}
+
+	if length := twittertext.WeightedLength(post.Text); length > limit {
+		return []string{fmt.Sprintf("post is %d characters, the limit is %d", length, limit)}
+	}
+
+	return nil
+}
+
+func checkHashtags(post Post, settings config.LintRuleSettings) []string {
+	limit := settings.Max
+	if limit <= 0 {
+		limit = defaultMaxHashtags
+	}
+
+	if count := len(hashtagPattern.FindAllStringSubmatch(withoutURLs(post.Text), -1)); count > limit {
+		return []string{fmt.Sprintf("%d hashtags, at most %d are allowed", count, limit)}
+	}
+
+	return nil
+}
+
+func checkBannedWords(post Post, settings config.LintRuleSettings) []string {
+	var messages []string
+
+	text := strings.ToLower(post.Text)
+
+	for _, word := range settings.Words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && containsWord(text, word) {
+			messages = append(messages, fmt.Sprintf("contains %q", word))
+		}
+	}
+
+	return messages
+}
+
+func checkLeadingMention(post Post, _ config.LintRuleSettings) []string {
+	if post.Reply {
+		return nil
+	}
+
+	if match := leadingMentionPattern.FindStringSubmatch(post.Text); match != nil {
+		return []string{fmt.Sprintf("starts with @%s, so X may treat it like a reply and show it to fewer people; put a character such as \".\" in front", match[1])}
+	}
+
+	return nil
+}
+
+func checkTrailingWhitespace(post Post, _ config.LintRuleSettings) []string {
+	var lines []string
+
+	for i, line := range strings.Split(post.Text, "\n") {
+		if line != strings.TrimRightFunc(line, unicode.IsSpace) {
+			lines = append(lines, fmt.Sprint(i+1))
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("whitespace at the end of line %s", strings.Join(lines, ", "))}
+}
+
+func checkQuotes(post Post, _ config.LintRuleSettings) []string {
+	var messages []string
+
+	if count := strings.Count(post.Text, `"`); count%2 != 0 {
+		messages = append(messages, `odd number of " quotes`)
+	}
+
+	for _, pair := range pairedQuotes {
+		// German quotes open with „ and close with “, so “ cannot be
+		// matched against ” there.
+		if pair[0] == '“' && strings.ContainsRune(post.Text, '„') {
+			continue
+		}
+
+		opening, closing := strings.Count(post.Text, string(pair[0])), strings.Count(post.Text, string(pair[1]))
+		if opening != closing {
+			messages = append(messages, fmt.Sprintf("%d %c but %d %c", opening, pair[0], closing, pair[1]))
+		}
+	}
+
+	return messages
+}
+
+func checkAltText(post Post, _ config.LintRuleSettings) []string {
+	var messages []string
+
+	for _, attachment := range post.Attachments {
+		if attachment.AltText == "" && media.NeedsAltText(attachment) {
+			messages = append(messages, fmt.Sprintf("%s has no alt text", media.Describe(attachment)))
+		}
+	}
+
+	return messages
+}
+
+func withoutURLs(text string) string {
+	spans := twittertext.ExtractURLs(text)
+	for i := len(spans) - 1; i >= 0; i-- {
+		text = text[:spans[i].Start] + " " + text[spans[i].End:]
+	}
+
+	return text
+}
+
+// containsWord reports whether word occurs in text on its own, so banning
+// "ass" does not flag "class". Both are expected in lower case.
+func containsWord(text string, word string) bool {
+	for offset := 0; offset < len(text); {
+		index := strings.Index(text[offset:], word)
+		if index < 0 {
+			return false
+		}
+
+		start := offset + index
+		end := start + len(word)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,296 @@
+package mastodon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"x-dev/internal/models"
+)
+
+// Scopes are what x-yapper asks for: reading the account it signed in as,
+// and posting statuses with media.
+const Scopes = "read:accounts write:statuses write:media"
+
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("mastodon request failed, status code: %d, response: %s", e.StatusCode, e.Body)
+}
+
+// NormalizeInstance turns "mastodon.social" or "https://mastodon.social/"
+// into the base URL requests are made against. Plain http is kept, for
+// instances running locally.
+func NormalizeInstance(instance string) (string, error) {
+	instance = strings.TrimSpace(instance)
+	if instance == "" {
+		return "", errors.New("no mastodon instance given")
+	}
+
+	if !strings.Contains(instance, "://") {
+		instance = "https://" + instance
+	}
+
+	u, err := url.Parse(instance)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", fmt.Errorf("%q is not a mastodon instance URL", instance)
+	}
+
+	return u.Scheme + "://" + strings.ToLower(u.Host) + strings.TrimSuffix(u.Path, "/"), nil
+}
+
+// RegisterApp creates the OAuth application x-yapper signs in with.
+func RegisterApp(ctx context.Context, instance string, redirectURI string) (*models.MastodonApp, error) {
+	form := url.Values{}
+	form.Set("client_name", "x-yapper")
+	form.Set("redirect_uris", redirectURI)
+	form.Set("scopes", Scopes)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, instance+"/api/v1/apps", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating app registration request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var app models.MastodonApp
+	if err := do(req, &app); err != nil {
+		return nil, fmt.Errorf("error registering app on %s: %w", instance, err)
+	}
+
+	return &app, nil
+}
+
+func AuthorizeURL(instance string, app *models.MastodonApp, redirectURI string, state string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", app.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", Scopes)
+	query.Set("state", state)
+
+	return instance + "/oauth/authorize?" + query.Encode()
+}
+
+func ExchangeCode(ctx context.Context, instance string, app *models.MastodonApp, redirectURI string, code string) (*models.MastodonToken, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("client_id", app.ClientID)
+	form.Set("client_secret", app.ClientSecret)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("scope", Scopes)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, instance+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token models.MastodonToken
+	if err := do(req, &token); err != nil {
+		return nil, fmt.Errorf("error exchanging code for token: %w", err)
+	}
+
+	return &token, nil
+}
+
+func VerifyCredentials(ctx context.Context, instance string, accessToken string) (*models.MastodonAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, instance+"/api/v1/accounts/verify_credentials", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating account request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var account models.MastodonAccount
+	if err := do(req, &account); err != nil {
+		return nil, fmt.Errorf("error fetching mastodon account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// GetInstance reads the instance configuration, falling back to the v1
+// endpoint for instances that predate v2.
+func GetInstance(ctx context.Context, instance string) (*models.MastodonInstance, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var info models.MastodonInstance
+
+	for _, path := range []string{"/api/v2/instance", "/api/v1/instance"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, instance+path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating instance request: %w", err)
+		}
+
+		err = do(req, &info)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error fetching instance configuration: %w", err)
+		}
+
+		return &info, nil
+	}
+
+	return nil, fmt.Errorf("%s does not look like a mastodon instance", instance)
+}
+
+// UploadMedia uploads the file at path with its alt text. Large files come
+// back before the instance has processed them, with no URL yet.
+func UploadMedia(ctx context.Context, instance string, path string, description string, accessToken string) (*models.MastodonMedia, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening media file: %w", err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("error creating media form field: %w", err)
+	}
+
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("error reading media file: %w", err)
+	}
+
+	if description != "" {
+		if err := writer.WriteField("description", description); err != nil {
+			return nil, fmt.Errorf("error writing description field: %w", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error finalizing media upload body: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 120*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, instance+"/api/v2/media", &body)
+	if err != nil {
+		return nil, fmt.Errorf("error creating media upload request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var media models.MastodonMedia
+	if err := do(req, &media); err != nil {
+		return nil, fmt.Errorf("error uploading %s: %w", filepath.Base(path), err)
+	}
+
+	return &media, nil
+}
+
+func GetMedia(ctx context.Context, instance string, mediaID string, accessToken string) (*models.MastodonMedia, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, instance+"/api/v1/media/"+url.PathEscape(mediaID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating media status request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var media models.MastodonMedia
+	if err := do(req, &media); err != nil {
+		return nil, fmt.Errorf("error checking media %s: %w", mediaID, err)
+	}
+
+	return &media, nil
+}
+
+// PostStatus publishes a status. The idempotency key makes a retried
+// request return the status created the first time instead of a copy.
+func PostStatus(ctx context.Context, instance string, status *models.MastodonStatusRequest, idempotencyKey string, accessToken string) (*models.MastodonStatus, error) {
+	jsonData, err := json.Marshal(status)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling status request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, instance+"/api/v1/statuses", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("error creating status request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+
+	var posted models.MastodonStatus
+	if err := do(req, &posted); err != nil {
+		return nil, fmt.Errorf("error posting to mastodon: %w", err)
+	}
+
+	return &posted, nil
+}
+
+func do(req *http.Request, v any) error {
+	client := &http.Client{
+		Timeout: 120 * time.Second,
+		Transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return nil
+		},
+		Jar: nil,
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package mastodon
+
+import (
+	"fmt"
+	"sync"
+
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+const loginsFile = "mastodon.json"
+
+var loginsMu sync.Mutex
+
+// stored keeps the app registered on each instance, so signing in another
+// profile on the same instance reuses it, and the login of each profile.
+type stored struct {
+	Apps   map[string]*models.MastodonApp   `json:"apps"`
+	Logins map[string]*models.MastodonLogin `json:"logins"`
+}
+
+func App(instance string) (*models.MastodonApp, bool, error) {
+	loginsMu.Lock()
+	defer loginsMu.Unlock()
+
+	s, err := load()
+	if err != nil {
+		return nil, false, err
+	}
+
+	app, ok := s.Apps[instance]
+
+	return app, ok, nil
+}
+
+func SaveApp(instance string, app *models.MastodonApp) error {
+	return update(func(s *stored) { s.Apps[instance] = app })
+}
+
+func SaveLogin(profile string, login *models.MastodonLogin) error {
+	return update(func(s *stored) { s.Logins[profile] = login })
+}
+
+func Login(profile string) (*models.MastodonLogin, error) {
+	loginsMu.Lock()
+	defer loginsMu.Unlock()
+
+	s, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	login, ok := s.Logins[profile]
+	if !ok {
+		return nil, fmt.Errorf("no mastodon login for profile %q, sign in with \"x-yapper mastodon login --profile %s\"", profile, profile)
+	}
+
+	return login, nil
+}
+
+// Secrets returns the client secrets and access tokens stored for
+// Mastodon, for the secret scanner.
+func Secrets() ([]string, error) {
+	loginsMu.Lock()
+	defer loginsMu.Unlock()
+
+	s, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []string
+
+	for _, app := range s.Apps {
+		secrets = append(secrets, app.ClientSecret)
+	}
+
+	for _, login := range s.Logins {
+		secrets = append(secrets, login.AccessToken)
+	}
+
+	return secrets, nil
+}
+
+func update(change func(*stored)) error {
+	loginsMu.Lock()
+	defer loginsMu.Unlock()
+
+	s, err := load()
+	if err != nil {
+		return err
+	}
+
+	change(s)
+
+	return store.Save(loginsFile, s)
+}
+
+func load() (*stored, error) {
+	s := &stored{}
+
+	if _, err := store.Load(loginsFile, s); err != nil {
+		return nil, fmt.Errorf("failed to load mastodon logins: %w", err)
+	}
+
+	if s.Apps == nil {
+		s.Apps = make(map[string]*models.MastodonApp)
+	}
+
+	if s.Logins == nil {
+		s.Logins = make(map[string]*models.MastodonLogin)
+	}
+
+	return s, nil
+}
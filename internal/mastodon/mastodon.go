@@ -0,0 +1,166 @@
+package mastodon
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"x-dev/internal/models"
+)
+
+const (
+	// defaultMaxCharacters and defaultReservedPerURL are Mastodon's own
+	// defaults, used when an instance does not report its limits.
+	defaultMaxCharacters  = 500
+	defaultReservedPerURL = 23
+
+	mediaPollTimeout = 5 * time.Minute
+)
+
+// mediaPollInterval is how often processing media is checked on.
+var mediaPollInterval = 2 * time.Second
+
+var (
+	urlPattern = regexp.MustCompile(`https?://[^\s<>"]+[^\s<>".,;:!?)\]'”]`)
+	// remoteMention matches the domain part of @user@domain, which Mastodon
+	// does not count towards the limit.
+	remoteMention = regexp.MustCompile(`(^|[^\w/])(@\w+)@[\w.-]+\w`)
+)
+
+// Options are the Mastodon settings of one post.
+type Options struct {
+	Visibility     string
+	ContentWarning string
+}
+
+// Limits are what an instance allows in a status.
+type Limits struct {
+	MaxCharacters  int
+	ReservedPerURL int
+	MaxMedia       int
+}
+
+func LimitsOf(info *models.MastodonInstance) Limits {
+	statuses := info.Configuration.Statuses
+
+	limits := Limits{
+		MaxCharacters:  statuses.MaxCharacters,
+		ReservedPerURL: statuses.CharactersReservedPerURL,
+		MaxMedia:       statuses.MaxMediaAttachments,
+	}
+
+	if limits.MaxCharacters == 0 {
+		limits.MaxCharacters = info.MaxTootChars
+	}
+
+	if limits.MaxCharacters == 0 {
+		limits.MaxCharacters = defaultMaxCharacters
+	}
+
+	if limits.ReservedPerURL == 0 {
+		limits.ReservedPerURL = defaultReservedPerURL
+	}
+
+	if limits.MaxMedia == 0 {
+		limits.MaxMedia = models.MaxMediaAttachments
+	}
+
+	return limits
+}
+
+// Length counts text the way Mastodon does: every link counts as
+// ReservedPerURL characters and a remote mention only by its username.
+func (l Limits) Length(text string) int {
+	text = remoteMention.ReplaceAllString(text, "$1$2")
+
+	length := 0
+	last := 0
+
+	for _, span := range urlPattern.FindAllStringIndex(text, -1) {
+		length += utf8.RuneCountInString(text[last:span[0]]) + l.ReservedPerURL
+		last = span[1]
+	}
+
+	return length + utf8.RuneCountInString(text[last:])
+}
+
+// Post uploads the attachments with their alt text and publishes text on
+// the account of login. key is sent as the idempotency key, so retrying
+// with the same key does not post twice.
+func Post(ctx context.Context, login *models.MastodonLogin, text string, attachments []*models.MediaAttachment, poll *models.Poll, opts Options, key string) (*models.MastodonStatus, error) {
+	status := &models.MastodonStatusRequest{
+		Status:      text,
+		Visibility:  opts.Visibility,
+		SpoilerText: opts.ContentWarning,
+		Sensitive:   opts.ContentWarning != "",
+	}
+
+	if poll != nil {
+		status.Poll = &models.MastodonPoll{
+			Options:   poll.Options,
+			ExpiresIn: poll.DurationMinutes * 60,
+		}
+	}
+
+	for _, attachment := range attachments {
+		media, err := UploadMedia(ctx, login.Instance, attachment.Path, attachment.AltText, login.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := waitForMedia(ctx, login, media); err != nil {
+			return nil, err
+		}
+
+		status.MediaIDs = append(status.MediaIDs, media.ID)
+	}
+
+	return PostStatus(ctx, login.Instance, status, key, login.AccessToken)
+}
+
+// waitForMedia waits until the instance has processed media, as a status
+// cannot attach media that is still being processed.
+func waitForMedia(ctx context.Context, login *models.MastodonLogin, media *models.MastodonMedia) error {
+	deadline := time.Now().Add(mediaPollTimeout)
+
+	for media.URL == nil {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("mastodon is still processing media %s, giving up", media.ID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(mediaPollInterval):
+		}
+
+		var err error
+		if media, err = GetMedia(ctx, login.Instance, media.ID, login.AccessToken); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Target is a profile's Mastodon account as the composer offers it.
+// Enabled and Defaults come from the profile's settings.
+type Target struct {
+	Login    *models.MastodonLogin
+	Limits   Limits
+	Enabled  bool
+	Defaults Options
+}
+
+// Label is how the account is shown, such as @alice@mastodon.social.
+func (t *Target) Label() string {
+	host := t.Login.Instance
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+
+	return "@" + t.Login.Username + "@" + host
+}
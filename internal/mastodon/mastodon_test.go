@@ -0,0 +1,485 @@
+package mastodon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"x-dev/internal/models"
+)
+
+const (
+	testRedirectURI = "http://localhost:8080/callback"
+	testToken       = "test-token"
+)
+
+// standIn is a local stand-in for the parts of the Mastodon API x-yapper
+// uses. It records what it was sent so tests can check the requests.
+type standIn struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu sync.Mutex
+	// v2Instance is served on /api/v2/instance; empty answers 404, as
+	// instances older than 4.0 do.
+	v2Instance string
+	v1Instance string
+	// processingPolls is how many times /api/v1/media/:id answers 206
+	// before the media has a URL.
+	processingPolls int
+	mediaPolls      int
+	mediaForm       map[string]string
+	mediaFile       string
+	status          map[string]any
+	idempotencyKey  string
+	forms           map[string]map[string]string
+}
+
+func newStandIn(t *testing.T) *standIn {
+	t.Helper()
+
+	s := &standIn{
+		t:          t,
+		v1Instance: `{"max_toot_chars":1000}`,
+		forms:      map[string]map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/apps", s.recordForm(`{"client_id":"client-id","client_secret":"client-secret"}`))
+	mux.HandleFunc("POST /oauth/token", s.recordForm(`{"access_token":"`+testToken+`","token_type":"Bearer","scope":"`+Scopes+`"}`))
+	mux.HandleFunc("GET /api/v1/accounts/verify_credentials", s.authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"42","username":"alice","acct":"alice","display_name":"Alice"}`))
+	}))
+	mux.HandleFunc("GET /api/v2/instance", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.v2Instance == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(s.v2Instance))
+	})
+	mux.HandleFunc("GET /api/v1/instance", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(s.v1Instance))
+	})
+	mux.HandleFunc("POST /api/v2/media", s.authorized(s.uploadMedia))
+	mux.HandleFunc("GET /api/v1/media/{id}", s.authorized(s.getMedia))
+	mux.HandleFunc("POST /api/v1/statuses", s.authorized(s.postStatus))
+
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *standIn) recordForm(response string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			s.t.Errorf("%s: parsing form: %v", r.URL.Path, err)
+		}
+
+		form := map[string]string{}
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+
+		s.mu.Lock()
+		s.forms[r.URL.Path] = form
+		s.mu.Unlock()
+
+		w.Write([]byte(response))
+	}
+}
+
+func (s *standIn) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			http.Error(w, `{"error":"The access token is invalid"}`, http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *standIn) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		s.t.Errorf("parsing media upload: %v", err)
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		s.t.Errorf("media upload has no file: %v", err)
+		http.Error(w, "no file", http.StatusUnprocessableEntity)
+		return
+	}
+	defer file.Close()
+
+	s.mu.Lock()
+	s.mediaForm = map[string]string{"description": r.FormValue("description")}
+	s.mediaFile = header.Filename
+	processing := s.processingPolls > 0
+	s.mu.Unlock()
+
+	if processing {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"id":"media-1","type":"video","url":null}`))
+
+		return
+	}
+
+	w.Write([]byte(`{"id":"media-1","type":"image","url":"https://files.example/media-1.png"}`))
+}
+
+func (s *standIn) getMedia(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mediaPolls++
+
+	if s.mediaPolls <= s.processingPolls {
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write([]byte(`{"id":"` + r.PathValue("id") + `","type":"video","url":null}`))
+
+		return
+	}
+
+	w.Write([]byte(`{"id":"` + r.PathValue("id") + `","type":"video","url":"https://files.example/media-1.mp4"}`))
+}
+
+func (s *standIn) postStatus(w http.ResponseWriter, r *http.Request) {
+	var status map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+		s.t.Errorf("decoding status: %v", err)
+	}
+
+	s.mu.Lock()
+	s.status = status
+	s.idempotencyKey = r.Header.Get("Idempotency-Key")
+	s.mu.Unlock()
+
+	if text, _ := status["status"].(string); len([]rune(text)) > 1000 {
+		http.Error(w, `{"error":"Validation failed: Text character limit of 1000 exceeded"}`, http.StatusUnprocessableEntity)
+		return
+	}
+
+	visibility, _ := status["visibility"].(string)
+	w.Write([]byte(`{"id":"109","url":"https://social.example/@alice/109","visibility":"` + visibility + `","created_at":"2026-10-18T12:00:00.000Z"}`))
+}
+
+func (s *standIn) login() *models.MastodonLogin {
+	return &models.MastodonLogin{
+		Instance:    s.server.URL,
+		AccountID:   "42",
+		Username:    "alice",
+		AccessToken: testToken,
+	}
+}
+
+func TestSignIn(t *testing.T) {
+	s := newStandIn(t)
+	ctx := context.Background()
+
+	app, err := RegisterApp(ctx, s.server.URL, testRedirectURI)
+	if err != nil {
+		t.Fatalf("RegisterApp: %v", err)
+	}
+
+	if app.ClientID != "client-id" || app.ClientSecret != "client-secret" {
+		t.Errorf("app = %+v", app)
+	}
+
+	wantApp := map[string]string{"client_name": "x-yapper", "redirect_uris": testRedirectURI, "scopes": Scopes}
+	for key, want := range wantApp {
+		if got := s.forms["/api/v1/apps"][key]; got != want {
+			t.Errorf("app registration %s = %q, want %q", key, got, want)
+		}
+	}
+
+	token, err := ExchangeCode(ctx, s.server.URL, app, testRedirectURI, "the-code")
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+
+	if token.AccessToken != testToken {
+		t.Errorf("access token = %q", token.AccessToken)
+	}
+
+	wantToken := map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          "the-code",
+		"client_id":     "client-id",
+		"client_secret": "client-secret",
+		"redirect_uri":  testRedirectURI,
+		"scope":         Scopes,
+	}
+	for key, want := range wantToken {
+		if got := s.forms["/oauth/token"][key]; got != want {
+			t.Errorf("token request %s = %q, want %q", key, got, want)
+		}
+	}
+
+	account, err := VerifyCredentials(ctx, s.server.URL, token.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyCredentials: %v", err)
+	}
+
+	if account.ID != "42" || account.Username != "alice" {
+		t.Errorf("account = %+v", account)
+	}
+
+	if _, err := VerifyCredentials(ctx, s.server.URL, "wrong"); err == nil {
+		t.Error("VerifyCredentials with a bad token succeeded")
+	}
+}
+
+func TestGetInstance(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("v2", func(t *testing.T) {
+		s := newStandIn(t)
+		s.v2Instance = `{"configuration":{"statuses":{"max_characters":5000,"max_media_attachments":6,"characters_reserved_per_url":30}}}`
+
+		info, err := GetInstance(ctx, s.server.URL)
+		if err != nil {
+			t.Fatalf("GetInstance: %v", err)
+		}
+
+		want := Limits{MaxCharacters: 5000, ReservedPerURL: 30, MaxMedia: 6}
+		if got := LimitsOf(info); got != want {
+			t.Errorf("limits = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("falls back to v1", func(t *testing.T) {
+		s := newStandIn(t)
+
+		info, err := GetInstance(ctx, s.server.URL)
+		if err != nil {
+			t.Fatalf("GetInstance: %v", err)
+		}
+
+		want := Limits{MaxCharacters: 1000, ReservedPerURL: defaultReservedPerURL, MaxMedia: models.MaxMediaAttachments}
+		if got := LimitsOf(info); got != want {
+			t.Errorf("limits = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		want := Limits{MaxCharacters: defaultMaxCharacters, ReservedPerURL: defaultReservedPerURL, MaxMedia: models.MaxMediaAttachments}
+		if got := LimitsOf(&models.MastodonInstance{}); got != want {
+			t.Errorf("limits = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestUploadMediaWaitsForProcessing(t *testing.T) {
+	s := newStandIn(t)
+	s.processingPolls = 2
+
+	previous := mediaPollInterval
+	mediaPollInterval = time.Millisecond
+	t.Cleanup(func() { mediaPollInterval = previous })
+
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, []byte("not really a video"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	login := s.login()
+
+	media, err := UploadMedia(ctx, login.Instance, path, "a short clip", login.AccessToken)
+	if err != nil {
+		t.Fatalf("UploadMedia: %v", err)
+	}
+
+	if media.URL != nil {
+		t.Fatalf("media URL = %q before processing finished", *media.URL)
+	}
+
+	if s.mediaFile != "clip.mp4" || s.mediaForm["description"] != "a short clip" {
+		t.Errorf("upload file = %q, form = %v", s.mediaFile, s.mediaForm)
+	}
+
+	if err := waitForMedia(ctx, login, media); err != nil {
+		t.Fatalf("waitForMedia: %v", err)
+	}
+
+	if s.mediaPolls != 3 {
+		t.Errorf("media polled %d times, want 3", s.mediaPolls)
+	}
+}
+
+func TestPost(t *testing.T) {
+	s := newStandIn(t)
+
+	path := filepath.Join(t.TempDir(), "cat.png")
+	if err := os.WriteFile(path, []byte("png"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	attachments := []*models.MediaAttachment{{Path: path, AltText: "a cat asleep"}}
+	opts := Options{Visibility: models.MastodonVisibilityUnlisted, ContentWarning: "cats"}
+
+	status, err := Post(context.Background(), s.login(), "hello fediverse", attachments, nil, opts, "x-yapper-123")
+	if err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	if status.URL != "https://social.example/@alice/109" || status.Visibility != models.MastodonVisibilityUnlisted {
+		t.Errorf("status = %+v", status)
+	}
+
+	if s.idempotencyKey != "x-yapper-123" {
+		t.Errorf("Idempotency-Key = %q", s.idempotencyKey)
+	}
+
+	if s.mediaForm["description"] != "a cat asleep" {
+		t.Errorf("alt text = %q", s.mediaForm["description"])
+	}
+
+	want := map[string]any{
+		"status":       "hello fediverse",
+		"visibility":   "unlisted",
+		"spoiler_text": "cats",
+		"sensitive":    true,
+		"media_ids":    []any{"media-1"},
+	}
+	for key, value := range want {
+		got, _ := json.Marshal(s.status[key])
+		expected, _ := json.Marshal(value)
+
+		if string(got) != string(expected) {
+			t.Errorf("status %s = %s, want %s", key, got, expected)
+		}
+	}
+
+	if _, ok := s.status["poll"]; ok {
+		t.Errorf("status without a poll sent poll %v", s.status["poll"])
+	}
+}
+
+func TestPostWithoutContentWarning(t *testing.T) {
+	s := newStandIn(t)
+	poll := &models.Poll{Options: []string{"tabs", "spaces"}, DurationMinutes: 90}
+
+	if _, err := Post(context.Background(), s.login(), "which one?", nil, poll, Options{Visibility: models.MastodonVisibilityPublic}, "key"); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+
+	for _, key := range []string{"spoiler_text", "sensitive", "media_ids"} {
+		if _, ok := s.status[key]; ok {
+			t.Errorf("status sent %s = %v", key, s.status[key])
+		}
+	}
+
+	got, _ := json.Marshal(s.status["poll"])
+	if string(got) != `{"expires_in":5400,"options":["tabs","spaces"]}` {
+		t.Errorf("poll = %s", got)
+	}
+}
+
+func TestPostRejected(t *testing.T) {
+	s := newStandIn(t)
+
+	text := make([]rune, 1001)
+	for i := range text {
+		text[i] = 'a'
+	}
+
+	_, err := Post(context.Background(), s.login(), string(text), nil, nil, Options{}, "key")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Post over the limit: err = %v", err)
+	}
+}
+
+func TestLength(t *testing.T) {
+	limits := Limits{MaxCharacters: 500, ReservedPerURL: 23}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"héllo wörld", 11},
+		{"see https://example.com/a/very/long/path?with=query", 4 + 23},
+		{"see https://example.com/post.", 4 + 23 + 1},
+		{"two http://a.example and https://b.example/x", 4 + 23 + 5 + 23},
+		{"bare example.com is not a link", 30},
+		{"hi @bob@mastodon.social!", len("hi @bob!")},
+		{"cc @bob and @carol@example.org", len("cc @bob and @carol")},
+		{"mail bob@example.org", 20},
+	}
+
+	for _, test := range tests {
+		if got := limits.Length(test.text); got != test.want {
+			t.Errorf("Length(%q) = %d, want %d", test.text, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeInstance(t *testing.T) {
+	tests := map[string]string{
+		"mastodon.social":           "https://mastodon.social",
+		"https://Mastodon.Social/":  "https://mastodon.social",
+		"http://localhost:3000":     "http://localhost:3000",
+		" https://example.org/sub/": "https://example.org/sub",
+	}
+
+	for input, want := range tests {
+		if got, err := NormalizeInstance(input); err != nil || got != want {
+			t.Errorf("NormalizeInstance(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "ftp://example.org"} {
+		if _, err := NormalizeInstance(input); err == nil {
+			t.Errorf("NormalizeInstance(%q) succeeded", input)
+		}
+	}
+}
+
+func TestLogins(t *testing.T) {
+	t.Setenv("X_YAPPER_HOME", t.TempDir())
+
+	if _, err := Login("default"); err == nil {
+		t.Fatal("Login without a stored login succeeded")
+	}
+
+	app := &models.MastodonApp{ClientID: "client-id", ClientSecret: "client-secret"}
+	if err := SaveApp("https://social.example", app); err != nil {
+		t.Fatal(err)
+	}
+
+	login := &models.MastodonLogin{Instance: "https://social.example", Username: "alice", AccessToken: testToken}
+	if err := SaveLogin("default", login); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, ok, err := App("https://social.example")
+	if err != nil || !ok || *stored != *app {
+		t.Errorf("App = %+v, %v, %v", stored, ok, err)
+	}
+
+	loaded, err := Login("default")
+	if err != nil || *loaded != *login {
+		t.Errorf("Login = %+v, %v", loaded, err)
+	}
+
+	secrets, err := Secrets()
+	if err != nil || len(secrets) != 2 {
+		t.Errorf("Secrets = %v, %v", secrets, err)
+	}
+}
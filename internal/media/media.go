@@ -0,0 +1,264 @@
+package media
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"x-dev/internal/models"
+
+	"github.com/dustin/go-humanize"
+)
+
+const attachHeaderPrefix = "attach:"
+
+var mediaCategories = map[string]string{
+	"image/jpeg":      models.MediaCategoryImage,
+	"image/png":       models.MediaCategoryImage,
+	"image/webp":      models.MediaCategoryImage,
+	"image/gif":       models.MediaCategoryGIF,
+	"video/mp4":       models.MediaCategoryVideo,
+	"video/quicktime": models.MediaCategoryVideo,
+}
+
+func LimitsFor(verified bool) models.MediaLimits {
+	limits := models.MediaLimits{
+		MaxImageSize:     models.MaxImageSize,
+		MaxGIFSize:       models.MaxGIFSize,
+		MaxVideoSize:     models.MaxVideoSize,
+		MinVideoDuration: 500 * time.Millisecond,
+		MaxVideoDuration: 140 * time.Second,
+	}
+
+	if verified {
+		limits.MaxVideoDuration = 4 * time.Hour
+	}
+
+	return limits
+}
+
+func Inspect(path string, limits models.MediaLimits) (*models.MediaAttachment, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attachments can be stored and sent later from another directory.
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, fmt.Errorf("error resolving media path: %w", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening media file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error reading media file info: %w", err)
+	}
+
+	if info.IsDir() {
+		return nil, fmt.Errorf("media path is a directory: %s", path)
+	}
+
+	header := make([]byte, 512)
+
+	n, err := file.Read(header)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error reading media file: %w", err)
+	}
+
+	mediaType := detectMediaType(header[:n], path)
+
+	category, ok := mediaCategories[mediaType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported media type %s for %s", mediaType, filepath.Base(path))
+	}
+
+	attachment := &models.MediaAttachment{
+		Path:      path,
+		MediaType: mediaType,
+		Category:  category,
+		Size:      info.Size(),
+	}
+
+	if err := checkLimits(attachment, file, limits); err != nil {
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func InspectAll(paths []string, limits models.MediaLimits) ([]*models.MediaAttachment, error) {
+	if len(paths) > models.MaxMediaAttachments {
+		return nil, fmt.Errorf("too many attachments: %d (maximum is %d)", len(paths), models.MaxMediaAttachments)
+	}
+
+	attachments := make([]*models.MediaAttachment, 0, len(paths))
+
+	for _, path := range paths {
+		attachment, err := Inspect(path, limits)
+		if err != nil {
+			return nil, err
+		}
+
+		if attachment.Category != models.MediaCategoryImage && len(paths) > 1 {
+			return nil, fmt.Errorf("%s must be the only attachment on a post", filepath.Base(attachment.Path))
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+func NeedsAltText(attachment *models.MediaAttachment) bool {
+	return attachment.Category != models.MediaCategoryVideo && strings.TrimSpace(attachment.AltText) == ""
+}
+
+func IsChunked(attachment *models.MediaAttachment) bool {
+	return attachment.Category != models.MediaCategoryImage
+}
+
+func detectMediaType(header []byte, path string) string {
+	mediaType := http.DetectContentType(header)
+
+	if mediaType == "application/octet-stream" && len(header) >= 12 && string(header[4:8]) == "ftyp" {
+		if string(header[8:12]) == "qt  " || strings.EqualFold(filepath.Ext(path), ".mov") {
+			return "video/quicktime"
+		}
+
+		return "video/mp4"
+	}
+
+	return mediaType
+}
+
+func checkLimits(attachment *models.MediaAttachment, file io.ReadSeeker, limits models.MediaLimits) error {
+	name := filepath.Base(attachment.Path)
+
+	var maxSize int64
+
+	switch attachment.Category {
+	case models.MediaCategoryGIF:
+		maxSize = limits.MaxGIFSize
+	case models.MediaCategoryVideo:
+		maxSize = limits.MaxVideoSize
+	default:
+		maxSize = limits.MaxImageSize
+	}
+
+	if attachment.Size > maxSize {
+		return fmt.Errorf("%s is %s, the limit for %s is %s",
+			name, humanize.IBytes(uint64(attachment.Size)), attachment.MediaType, humanize.IBytes(uint64(maxSize)))
+	}
+
+	if attachment.Category != models.MediaCategoryVideo {
+		return nil
+	}
+
+	duration, err := videoDuration(file)
+	if err != nil {
+		return fmt.Errorf("could not read duration of %s: %w", name, err)
+	}
+
+	if duration < limits.MinVideoDuration || duration > limits.MaxVideoDuration {
+		return fmt.Errorf("%s is %s long, videos must be between %s and %s",
+			name, duration.Round(100*time.Millisecond), limits.MinVideoDuration, limits.MaxVideoDuration)
+	}
+
+	attachment.Duration = duration
+
+	return nil
+}
+
+// ParseAttachHeader strips leading "attach: <path>" lines from an editor
+// buffer and returns the remaining text along with the referenced paths.
+func ParseAttachHeader(content string) (string, []string) {
+	var paths []string
+
+	consumed := 0
+
+	// SplitAfter keeps each line ending, so consumed counts "\r\n" as well
+	// as "\n".
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if !strings.HasPrefix(strings.ToLower(trimmed), attachHeaderPrefix) {
+			break
+		}
+
+		path := strings.TrimSpace(trimmed[len(attachHeaderPrefix):])
+		if path != "" {
+			paths = append(paths, path)
+		}
+
+		consumed += len(line)
+	}
+
+	if len(paths) == 0 {
+		return content, nil
+	}
+
+	return strings.TrimLeft(content[consumed:], "\r\n"), paths
+}
+
+func FormatAttachHeader(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	var header strings.Builder
+
+	for _, path := range paths {
+		header.WriteString(attachHeaderPrefix + " " + path + "\n")
+	}
+
+	header.WriteString("\n")
+
+	return header.String()
+}
+
+func Describe(attachment *models.MediaAttachment) string {
+	if attachment.Duration > 0 {
+		return fmt.Sprintf("%s (%s, %s, %s)",
+			filepath.Base(attachment.Path), attachment.MediaType, humanize.IBytes(uint64(attachment.Size)),
+			attachment.Duration.Round(100*time.Millisecond))
+	}
+
+	return fmt.Sprintf("%s (%s, %s)",
+		filepath.Base(attachment.Path), attachment.MediaType, humanize.IBytes(uint64(attachment.Size)))
+}
+
+func MediaIDs(attachments []*models.MediaAttachment) *models.PostMedia {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		ids = append(ids, attachment.MediaID)
+	}
+
+	return &models.PostMedia{MediaIDs: ids}
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error resolving home directory: %w", err)
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
@@ -0,0 +1,109 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// videoDuration reads the movie header (moov/mvhd) of an MP4 or QuickTime
+// file to determine its duration without decoding any media.
+func videoDuration(r io.ReadSeeker) (time.Duration, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, fmt.Errorf("error seeking video file: %w", err)
+	}
+
+	moovStart, moovEnd, err := findBox(r, 0, end, "moov")
+	if err != nil {
+		return 0, err
+	}
+
+	mvhdStart, _, err := findBox(r, moovStart, moovEnd, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := r.Seek(mvhdStart, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("error seeking video file: %w", err)
+	}
+
+	versionAndFlags := make([]byte, 4)
+	if _, err := io.ReadFull(r, versionAndFlags); err != nil {
+		return 0, fmt.Errorf("error reading mvhd box: %w", err)
+	}
+
+	var timescale uint32
+	var duration uint64
+
+	if versionAndFlags[0] == 1 {
+		fields := make([]byte, 28)
+		if _, err := io.ReadFull(r, fields); err != nil {
+			return 0, fmt.Errorf("error reading mvhd box: %w", err)
+		}
+
+		timescale = binary.BigEndian.Uint32(fields[16:20])
+		duration = binary.BigEndian.Uint64(fields[20:28])
+	} else {
+		fields := make([]byte, 16)
+		if _, err := io.ReadFull(r, fields); err != nil {
+			return 0, fmt.Errorf("error reading mvhd box: %w", err)
+		}
+
+		timescale = binary.BigEndian.Uint32(fields[8:12])
+		duration = uint64(binary.BigEndian.Uint32(fields[12:16]))
+	}
+
+	if timescale == 0 {
+		return 0, errors.New("invalid mvhd timescale")
+	}
+
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+// findBox scans the sibling boxes in [start, end) and returns the payload
+// range of the first box with the given type.
+func findBox(r io.ReadSeeker, start, end int64, boxType string) (int64, int64, error) {
+	header := make([]byte, 8)
+	offset := start
+
+	for offset+8 <= end {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return 0, 0, fmt.Errorf("error seeking video file: %w", err)
+		}
+
+		if _, err := io.ReadFull(r, header); err != nil {
+			return 0, 0, fmt.Errorf("error reading box header: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			largeSize := make([]byte, 8)
+			if _, err := io.ReadFull(r, largeSize); err != nil {
+				return 0, 0, fmt.Errorf("error reading box header: %w", err)
+			}
+
+			size = int64(binary.BigEndian.Uint64(largeSize))
+			headerSize = 16
+		}
+
+		if size < headerSize {
+			return 0, 0, fmt.Errorf("malformed %q box at offset %d", string(header[4:8]), offset)
+		}
+
+		if string(header[4:8]) == boxType {
+			return offset + headerSize, offset + size, nil
+		}
+
+		offset += size
+	}
+
+	return 0, 0, fmt.Errorf("no %s box found", boxType)
+}
@@ -0,0 +1,378 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+const (
+	uploadsFile          = "uploads.json"
+	defaultUploadExpiry  = 24 * time.Hour
+	maxStatusCheckPeriod = 30 * time.Second
+)
+
+type UploadOptions struct {
+	ChunkSize int64
+	Workers   int
+	Progress  func(name string, stage string, percent int)
+	// Account keeps the resumable state of chunked uploads apart when
+	// several accounts upload the same file at once.
+	Account string
+}
+
+type uploadState struct {
+	MediaID   string    `json:"media_id"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	ChunkSize int64     `json:"chunk_size"`
+	Completed []bool    `json:"completed"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var uploadsMu sync.Mutex
+
+func UploadAll(ctx context.Context, attachments []*models.MediaAttachment, accessToken string, opts UploadOptions) error {
+	for _, attachment := range attachments {
+		if attachment.MediaID != "" {
+			continue
+		}
+
+		if err := Upload(ctx, attachment, accessToken, opts); err != nil {
+			return fmt.Errorf("error uploading %s: %w", filepath.Base(attachment.Path), err)
+		}
+
+		if attachment.AltText == "" {
+			continue
+		}
+
+		if err := api.SetMediaAltText(ctx, attachment.MediaID, attachment.AltText, accessToken); err != nil {
+			return fmt.Errorf("error setting alt text for %s: %w", filepath.Base(attachment.Path), err)
+		}
+	}
+
+	return nil
+}
+
+func Upload(ctx context.Context, attachment *models.MediaAttachment, accessToken string, opts UploadOptions) error {
+	if !IsChunked(attachment) {
+		opts.report(attachment, "uploading", 0)
+
+		uploadResp, err := api.UploadMedia(ctx, attachment, accessToken)
+		if err != nil {
+			return err
+		}
+
+		attachment.MediaID = uploadResp.Data.ID
+		opts.report(attachment, "uploading", 100)
+
+		return nil
+	}
+
+	return uploadChunked(ctx, attachment, accessToken, opts)
+}
+
+func uploadChunked(ctx context.Context, attachment *models.MediaAttachment, accessToken string, opts UploadOptions) error {
+	info, err := os.Stat(attachment.Path)
+	if err != nil {
+		return fmt.Errorf("error reading media file info: %w", err)
+	}
+
+	if opts.ChunkSize <= 0 {
+		return errors.New("chunk size must be positive")
+	}
+
+	state, resumed, err := loadOrInitUpload(ctx, attachment, info, accessToken, opts)
+	if err != nil {
+		return err
+	}
+
+	if resumed {
+		opts.report(attachment, "resuming", state.percentComplete())
+	}
+
+	if err := appendSegments(ctx, attachment, state, accessToken, opts); err != nil {
+		var apiErr *api.MediaAPIError
+		if resumed && errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			_ = forgetUpload(opts.stateKey(attachment))
+			return fmt.Errorf("previous upload session is no longer valid, try again: %w", err)
+		}
+
+		return err
+	}
+
+	finalizeResp, err := api.FinalizeChunkedUpload(ctx, state.MediaID, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if err := waitForProcessing(ctx, attachment, state.MediaID, finalizeResp.Data.ProcessingInfo, accessToken, opts); err != nil {
+		_ = forgetUpload(opts.stateKey(attachment))
+		return err
+	}
+
+	attachment.MediaID = state.MediaID
+
+	return forgetUpload(opts.stateKey(attachment))
+}
+
+func loadOrInitUpload(ctx context.Context, attachment *models.MediaAttachment, info os.FileInfo, accessToken string, opts UploadOptions) (*uploadState, bool, error) {
+	chunkSize := opts.ChunkSize
+
+	states, err := loadUploads()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if state, ok := states[opts.stateKey(attachment)]; ok {
+		if state.Size == info.Size() && state.ModTime.Equal(info.ModTime()) &&
+			state.ChunkSize == chunkSize && time.Now().Add(time.Minute).Before(state.ExpiresAt) {
+			return state, true, nil
+		}
+	}
+
+	initResp, err := api.InitChunkedUpload(ctx, attachment, accessToken)
+	if err != nil {
+		return nil, false, err
+	}
+
+	expiresIn := time.Duration(initResp.Data.ExpiresAfterSecs) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = defaultUploadExpiry
+	}
+
+	segments := (info.Size() + chunkSize - 1) / chunkSize
+
+	state := &uploadState{
+		MediaID:   initResp.Data.ID,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		ChunkSize: chunkSize,
+		Completed: make([]bool, segments),
+		ExpiresAt: time.Now().Add(expiresIn),
+	}
+
+	if err := saveUpload(opts.stateKey(attachment), state); err != nil {
+		return nil, false, err
+	}
+
+	return state, false, nil
+}
+
+func appendSegments(ctx context.Context, attachment *models.MediaAttachment, state *uploadState, accessToken string, opts UploadOptions) error {
+	file, err := os.Open(attachment.Path)
+	if err != nil {
+		return fmt.Errorf("error opening media file: %w", err)
+	}
+	defer file.Close()
+
+	var pending []int
+
+	for index, done := range state.Completed {
+		if !done {
+			pending = append(pending, index)
+		}
+	}
+
+	workers := opts.Workers
+	if workers < 1 || attachment.Category != models.MediaCategoryVideo {
+		workers = 1
+	}
+
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	segmentChan := make(chan int)
+	errChan := make(chan error, workers)
+
+	var wGroup sync.WaitGroup
+	var stateMu sync.Mutex
+
+	for range workers {
+		wGroup.Add(1)
+
+		go func() {
+			defer wGroup.Done()
+
+			chunk := make([]byte, state.ChunkSize)
+
+			for index := range segmentChan {
+				n, err := file.ReadAt(chunk, int64(index)*state.ChunkSize)
+				if err != nil && !errors.Is(err, io.EOF) {
+					errChan <- fmt.Errorf("error reading segment %d: %w", index, err)
+					cancel()
+
+					return
+				}
+
+				if err := api.AppendChunk(ctx, state.MediaID, index, chunk[:n], accessToken); err != nil {
+					errChan <- fmt.Errorf("error appending segment %d: %w", index, err)
+					cancel()
+
+					return
+				}
+
+				stateMu.Lock()
+				state.Completed[index] = true
+				percent := state.percentComplete()
+				saveErr := saveUpload(opts.stateKey(attachment), state)
+				stateMu.Unlock()
+
+				if saveErr != nil {
+					errChan <- saveErr
+					cancel()
+
+					return
+				}
+
+				opts.report(attachment, "uploading", percent)
+			}
+		}()
+	}
+
+feed:
+	for _, index := range pending {
+		select {
+		case segmentChan <- index:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(segmentChan)
+	wGroup.Wait()
+	close(errChan)
+
+	if err := <-errChan; err != nil {
+		return err
+	}
+
+	return ctx.Err()
+}
+
+func waitForProcessing(ctx context.Context, attachment *models.MediaAttachment, mediaID string, info *models.ProcessingInfo, accessToken string, opts UploadOptions) error {
+	for info != nil {
+		switch info.State {
+		case models.ProcessingSucceeded:
+			opts.report(attachment, "processing", 100)
+			return nil
+
+		case models.ProcessingFailed:
+			if info.Error != nil {
+				return fmt.Errorf("media processing failed: %s", info.Error.Message)
+			}
+
+			return errors.New("media processing failed")
+		}
+
+		opts.report(attachment, "processing", info.ProgressPercent)
+
+		wait := time.Duration(info.CheckAfterSecs) * time.Second
+		if wait <= 0 {
+			wait = time.Second
+		}
+
+		if wait > maxStatusCheckPeriod {
+			wait = maxStatusCheckPeriod
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		statusResp, err := api.MediaUploadStatus(ctx, mediaID, accessToken)
+		if err != nil {
+			return err
+		}
+
+		info = statusResp.Data.ProcessingInfo
+	}
+
+	return nil
+}
+
+func (s *uploadState) percentComplete() int {
+	if len(s.Completed) == 0 {
+		return 100
+	}
+
+	done := 0
+
+	for _, completed := range s.Completed {
+		if completed {
+			done++
+		}
+	}
+
+	return done * 100 / len(s.Completed)
+}
+
+func (o UploadOptions) report(attachment *models.MediaAttachment, stage string, percent int) {
+	if o.Progress != nil {
+		o.Progress(filepath.Base(attachment.Path), stage, percent)
+	}
+}
+
+func (o UploadOptions) stateKey(attachment *models.MediaAttachment) string {
+	if o.Account == "" {
+		return attachment.Path
+	}
+
+	return o.Account + ":" + attachment.Path
+}
+
+func loadUploads() (map[string]*uploadState, error) {
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+
+	states := map[string]*uploadState{}
+
+	if _, err := store.Load(uploadsFile, &states); err != nil {
+		return nil, err
+	}
+
+	return states, nil
+}
+
+func saveUpload(path string, state *uploadState) error {
+	return updateUploads(func(states map[string]*uploadState) {
+		states[path] = state
+	})
+}
+
+func forgetUpload(path string) error {
+	return updateUploads(func(states map[string]*uploadState) {
+		delete(states, path)
+	})
+}
+
+func updateUploads(update func(map[string]*uploadState)) error {
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+
+	states := map[string]*uploadState{}
+
+	if _, err := store.Load(uploadsFile, &states); err != nil {
+		return err
+	}
+
+	update(states)
+
+	return store.Save(uploadsFile, states)
+}
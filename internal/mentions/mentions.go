@@ -0,0 +1,310 @@
+package mentions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/history"
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+	"x-dev/internal/twittertext"
+)
+
+const (
+	handlesFile = "handles.json"
+
+	// maxKnownHandles caps the cache; the handles seen longest ago go first.
+	maxKnownHandles = 5000
+)
+
+const (
+	StatusFound       = "found"
+	StatusNotFound    = "not found"
+	StatusSuspended   = "suspended"
+	StatusUnavailable = "unavailable"
+)
+
+// Result is what the users lookup said about one username. User is set
+// when the account was found.
+type Result struct {
+	Username string
+	Status   string
+	Detail   string
+	User     *models.User
+}
+
+var handlesMu sync.Mutex
+
+// Usernames returns the usernames mentioned in text, once each, in the
+// order they first appear.
+func Usernames(text string) []string {
+	var usernames []string
+
+	seen := map[string]bool{}
+
+	for _, span := range twittertext.ExtractMentions(text) {
+		key := strings.ToLower(span.Text)
+		if !seen[key] {
+			seen[key] = true
+			usernames = append(usernames, span.Text)
+		}
+	}
+
+	return usernames
+}
+
+// Resolve looks usernames up on X, 100 per request, and remembers the
+// accounts it finds for completion. Results are keyed by the lower-cased
+// username.
+func Resolve(ctx context.Context, usernames []string, accessToken string) (map[string]Result, error) {
+	results := map[string]Result{}
+
+	for start := 0; start < len(usernames); start += api.MaxUsersPerLookup {
+		end := min(start+api.MaxUsersPerLookup, len(usernames))
+
+		resp, _, err := api.LookupUsers(ctx, usernames[start:end], accessToken)
+		if err != nil {
+			return results, err
+		}
+
+		for i := range resp.Data {
+			user := &resp.Data[i]
+			results[strings.ToLower(user.Username)] = Result{Username: user.Username, Status: StatusFound, User: user}
+		}
+
+		for _, resourceErr := range resp.Errors {
+			results[strings.ToLower(resourceErr.Value)] = Result{
+				Username: resourceErr.Value,
+				Status:   classify(resourceErr),
+				Detail:   resourceErr.Detail,
+			}
+		}
+
+		if err := Remember(resp.Data); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+func classify(resourceErr models.ResourceError) string {
+	switch {
+	case strings.Contains(strings.ToLower(resourceErr.Detail), "suspended"):
+		return StatusSuspended
+	case strings.HasSuffix(resourceErr.Type, "/resource-not-found"):
+		return StatusNotFound
+	default:
+		return StatusUnavailable
+	}
+}
+
+// Remember adds users to the handles known for completion, or refreshes
+// them when they are already known.
+func Remember(users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	var handles []models.KnownHandle
+	if _, err := store.Load(handlesFile, &handles); err != nil {
+		return err
+	}
+
+	index := map[string]int{}
+	for i, handle := range handles {
+		index[strings.ToLower(handle.Username)] = i
+	}
+
+	now := time.Now()
+
+	for _, user := range users {
+		handle := models.KnownHandle{Username: user.Username, Name: user.Name, ID: user.ID, SeenAt: now}
+
+		if i, ok := index[strings.ToLower(user.Username)]; ok {
+			handles[i] = handle
+			continue
+		}
+
+		index[strings.ToLower(user.Username)] = len(handles)
+		handles = append(handles, handle)
+	}
+
+	if len(handles) > maxKnownHandles {
+		sort.SliceStable(handles, func(i, j int) bool {
+			return handles[i].SeenAt.After(handles[j].SeenAt)
+		})
+
+		handles = handles[:maxKnownHandles]
+	}
+
+	return store.Save(handlesFile, handles)
+}
+
+func Known() ([]models.KnownHandle, error) {
+	handlesMu.Lock()
+	defer handlesMu.Unlock()
+
+	var handles []models.KnownHandle
+	if _, err := store.Load(handlesFile, &handles); err != nil {
+		return nil, err
+	}
+
+	return handles, nil
+}
+
+// Complete returns up to limit handles starting with prefix, with their @,
+// or hashtags when prefix starts with #. Handles you mentioned most often
+// in sent posts come first, then the ones seen most recently; hashtags are
+// taken from sent posts only.
+func Complete(prefix string, limit int) ([]string, error) {
+	records, err := history.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if tag, ok := strings.CutPrefix(prefix, "#"); ok {
+		return completeHashtags(tag, records, limit), nil
+	}
+
+	prefix = strings.ToLower(strings.TrimPrefix(prefix, "@"))
+
+	known, err := Known()
+	if err != nil {
+		return nil, err
+	}
+
+	type candidate struct {
+		username string
+		uses     int
+		seenAt   time.Time
+	}
+
+	candidates := map[string]*candidate{}
+
+	for _, handle := range known {
+		if key := strings.ToLower(handle.Username); strings.HasPrefix(key, prefix) {
+			candidates[key] = &candidate{username: handle.Username, seenAt: handle.SeenAt}
+		}
+	}
+
+	for _, rec := range records {
+		if rec.Action == models.HistoryActionDelete {
+			continue
+		}
+
+		for _, span := range twittertext.ExtractMentions(rec.Text) {
+			key := strings.ToLower(span.Text)
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+
+			c, ok := candidates[key]
+			if !ok {
+				c = &candidate{username: span.Text}
+				candidates[key] = c
+			}
+
+			c.uses++
+
+			if rec.Time.After(c.seenAt) {
+				c.seenAt = rec.Time
+			}
+		}
+	}
+
+	sorted := make([]*candidate, 0, len(candidates))
+	for _, c := range candidates {
+		sorted = append(sorted, c)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].uses != sorted[j].uses {
+			return sorted[i].uses > sorted[j].uses
+		}
+
+		if !sorted[i].seenAt.Equal(sorted[j].seenAt) {
+			return sorted[i].seenAt.After(sorted[j].seenAt)
+		}
+
+		return strings.ToLower(sorted[i].username) < strings.ToLower(sorted[j].username)
+	})
+
+	var completions []string
+
+	for _, c := range sorted {
+		if limit > 0 && len(completions) == limit {
+			break
+		}
+
+		completions = append(completions, "@"+c.username)
+	}
+
+	return completions, nil
+}
+
+func completeHashtags(prefix string, records []models.HistoryRecord, limit int) []string {
+	prefix = strings.ToLower(prefix)
+
+	uses := map[string]int{}
+	spelling := map[string]string{}
+
+	for _, rec := range records {
+		if rec.Action == models.HistoryActionDelete {
+			continue
+		}
+
+		for _, span := range twittertext.ExtractHashtags(rec.Text) {
+			key := strings.ToLower(span.Text)
+			if strings.HasPrefix(key, prefix) {
+				uses[key]++
+				spelling[key] = span.Text
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(uses))
+	for key := range uses {
+		tags = append(tags, key)
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if uses[tags[i]] != uses[tags[j]] {
+			return uses[tags[i]] > uses[tags[j]]
+		}
+
+		return tags[i] < tags[j]
+	})
+
+	var completions []string
+
+	for _, key := range tags {
+		if limit > 0 && len(completions) == limit {
+			break
+		}
+
+		completions = append(completions, "#"+spelling[key])
+	}
+
+	return completions
+}
+
+// Describe says what is wrong with a mention that did not resolve.
+func Describe(result Result) string {
+	switch result.Status {
+	case StatusNotFound:
+		return fmt.Sprintf("@%s does not exist", result.Username)
+	case StatusSuspended:
+		return fmt.Sprintf("@%s is suspended", result.Username)
+	default:
+		return fmt.Sprintf("@%s could not be checked: %s", result.Username, result.Detail)
+	}
+}
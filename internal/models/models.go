@@ -13,7 +13,41 @@ const (
 
 var (
 	AuthTokenChan = make(chan string, 1)
-	Scopes        = "tweet.read tweet.write users.read offline.access"
+	Scopes        = "tweet.read tweet.write users.read offline.access media.write"
+)
+
+const (
+	MaxMediaAttachments = 4
+	MaxImageSize        = 5 * 1024 * 1024
+	MaxGIFSize          = 15 * 1024 * 1024
+	MaxVideoSize        = 512 * 1024 * 1024
+)
+
+const MaxAltTextLength = 1000
+
+const (
+	AltTextPolicyOff     = "off"
+	AltTextPolicyWarn    = "warn"
+	AltTextPolicyRequire = "require"
+)
+
+const (
+	ReplySettingsEveryone  = "everyone"
+	ReplySettingsFollowing = "following"
+	ReplySettingsMentioned = "mentionedUsers"
+)
+
+const (
+	MediaCategoryImage = "tweet_image"
+	MediaCategoryGIF   = "tweet_gif"
+	MediaCategoryVideo = "tweet_video"
+)
+
+const (
+	ProcessingPending    = "pending"
+	ProcessingInProgress = "in_progress"
+	ProcessingSucceeded  = "succeeded"
+	ProcessingFailed     = "failed"
 )
 
 type TokenResponse struct {
@@ -36,7 +70,70 @@ type UserResponse struct {
 }
 
 type Post struct {
-	Text string `json:"text"`
+	Text          string     `json:"text"`
+	Media         *PostMedia `json:"media,omitempty"`
+	Poll          *Poll      `json:"poll,omitempty"`
+	ReplySettings string     `json:"reply_settings,omitempty"`
+	CommunityID   string     `json:"community_id,omitempty"`
+}
+
+type Poll struct {
+	Options         []string `json:"options"`
+	DurationMinutes int      `json:"duration_minutes"`
+}
+
+type PostMedia struct {
+	MediaIDs []string `json:"media_ids"`
+}
+
+type MediaAttachment struct {
+	Path      string        `json:"path"`
+	MediaType string        `json:"media_type"`
+	Category  string        `json:"category"`
+	Size      int64         `json:"size"`
+	Duration  time.Duration `json:"duration,omitempty"`
+	AltText   string        `json:"alt_text,omitempty"`
+	MediaID   string        `json:"media_id,omitempty"`
+}
+
+type MediaMetadataRequest struct {
+	ID       string `json:"id"`
+	Metadata struct {
+		AltText struct {
+			Text string `json:"text"`
+		} `json:"alt_text"`
+	} `json:"metadata"`
+}
+
+type MediaLimits struct {
+	MaxImageSize     int64
+	MaxGIFSize       int64
+	MaxVideoSize     int64
+	MinVideoDuration time.Duration
+	MaxVideoDuration time.Duration
+}
+
+type MediaUploadResponse struct {
+	Data struct {
+		ID               string          `json:"id"`
+		MediaKey         string          `json:"media_key"`
+		Size             int64           `json:"size"`
+		ExpiresAfterSecs int             `json:"expires_after_secs"`
+		ProcessingInfo   *ProcessingInfo `json:"processing_info,omitempty"`
+	} `json:"data"`
+}
+
+type ProcessingInfo struct {
+	State           string           `json:"state"`
+	CheckAfterSecs  int              `json:"check_after_secs,omitempty"`
+	ProgressPercent int              `json:"progress_percent,omitempty"`
+	Error           *ProcessingError `json:"error,omitempty"`
+}
+
+type ProcessingError struct {
+	Code    int    `json:"code"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
 }
 
 type PostResponse struct {
@@ -46,18 +143,294 @@ type PostResponse struct {
 	} `json:"data"`
 }
 
+type DeleteResponse struct {
+	Data struct {
+		Deleted bool `json:"deleted"`
+	} `json:"data"`
+}
+
+// LatestPost is the post new thread entries reply to. OutboxID is set
+// instead of PostID while that post waits in the outbox.
 type LatestPost struct {
-	Text   string `json:"text"`
-	PostID string `json:"post_id"`
+	Text     string `json:"text"`
+	PostID   string `json:"post_id"`
+	OutboxID string `json:"outbox_id,omitempty"`
 }
 
 type Reply struct {
-	ReplyID string `json:"in_reply_to_tweet_id,omitempty"`
+	ReplyID             string   `json:"in_reply_to_tweet_id,omitempty"`
+	ExcludeReplyUserIDs []string `json:"exclude_reply_user_ids,omitempty"`
 }
 
 type ReplyPost struct {
-	Text  string `json:"text"`
-	Reply *Reply `json:"reply,omitempty"`
+	Text          string     `json:"text"`
+	Media         *PostMedia `json:"media,omitempty"`
+	Reply         *Reply     `json:"reply,omitempty"`
+	QuoteTweetID  string     `json:"quote_tweet_id,omitempty"`
+	ReplySettings string     `json:"reply_settings,omitempty"`
+}
+
+type Thread struct {
+	ID        string       `json:"id"`
+	DraftID   string       `json:"draft_id,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	Posts     []ThreadPost `json:"posts"`
+
+	// ReplySettings limits who can reply to the first post; X only takes
+	// it on posts that start a conversation.
+	ReplySettings string `json:"reply_settings,omitempty"`
+
+	// AllowSecrets is set once the user chose to send the thread despite
+	// the secret scanner, so a resumed thread is not held back again.
+	AllowSecrets bool `json:"allow_secrets,omitempty"`
+}
+
+type ThreadPost struct {
+	Text        string             `json:"text"`
+	Attachments []*MediaAttachment `json:"attachments,omitempty"`
+	PostID      string             `json:"post_id,omitempty"`
+}
+
+const (
+	DraftKindPost   = "post"
+	DraftKindReply  = "reply"
+	DraftKindQuote  = "quote"
+	DraftKindPoll   = "poll"
+	DraftKindThread = "thread"
+)
+
+type Draft struct {
+	ID        string         `json:"id"`
+	Kind      string         `json:"kind"`
+	TargetID  string         `json:"target_id,omitempty"`
+	Saved     bool           `json:"saved"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	Versions  []DraftVersion `json:"versions"`
+}
+
+type DraftVersion struct {
+	SavedAt time.Time `json:"saved_at"`
+	Text    string    `json:"text"`
+}
+
+// OutgoingPost is a post described by file paths and IDs rather than
+// uploaded media, so it can be stored and sent later.
+type OutgoingPost struct {
+	Text                string   `json:"text"`
+	MediaPaths          []string `json:"media_paths,omitempty"`
+	AltTexts            []string `json:"alt_texts,omitempty"`
+	Poll                *Poll    `json:"poll,omitempty"`
+	ReplyTo             string   `json:"reply_to,omitempty"`
+	ExcludeReplyUserIDs []string `json:"exclude_reply_user_ids,omitempty"`
+	QuoteID             string   `json:"quote_id,omitempty"`
+	ReplySettings       string   `json:"reply_settings,omitempty"`
+	CommunityID         string   `json:"community_id,omitempty"`
+	AllowSecrets        bool     `json:"allow_secrets,omitempty"`
+}
+
+const (
+	ScheduleStatusPending = "pending"
+	ScheduleStatusSending = "sending"
+	ScheduleStatusSent    = "sent"
+	ScheduleStatusFailed  = "failed"
+	ScheduleStatusSkipped = "skipped"
+)
+
+type ScheduledPost struct {
+	ID        string     `json:"id"`
+	Profile   string     `json:"profile"`
+	At        time.Time  `json:"at"`
+	TimeZone  string     `json:"time_zone"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts,omitempty"`
+	PostID    string     `json:"post_id,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	OutgoingPost
+}
+
+const (
+	DuplicatePolicySkip  = "skip"
+	DuplicatePolicyAlert = "alert"
+)
+
+// RecurringPost is a schedule entry that repeats on a cron expression. Its
+// Template is rendered with text/template for every run.
+type RecurringPost struct {
+	ID              string     `json:"id"`
+	Profile         string     `json:"profile"`
+	Cron            string     `json:"cron"`
+	TimeZone        string     `json:"time_zone"`
+	Template        string     `json:"template"`
+	MediaPaths      []string   `json:"media_paths,omitempty"`
+	AltTexts        []string   `json:"alt_texts,omitempty"`
+	ReplySettings   string     `json:"reply_settings,omitempty"`
+	CommunityID     string     `json:"community_id,omitempty"`
+	DuplicatePolicy string     `json:"duplicate_policy,omitempty"`
+	AllowSecrets    bool       `json:"allow_secrets,omitempty"`
+	Next            time.Time  `json:"next"`
+	Runs            int        `json:"runs"`
+	Attempts        int        `json:"attempts,omitempty"`
+	LastText        string     `json:"last_text,omitempty"`
+	LastPostID      string     `json:"last_post_id,omitempty"`
+	LastRun         *time.Time `json:"last_run,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// OutboxItem is a post that could not be sent because X was unreachable.
+// ReplyToItem chains queued replies to a queued parent; its post ID becomes
+// ReplyTo once the parent is sent. PostID is set when the item went out.
+type OutboxItem struct {
+	ID          string    `json:"id"`
+	Profile     string    `json:"profile"`
+	QueuedAt    time.Time `json:"queued_at"`
+	ReplyToItem string    `json:"reply_to_item,omitempty"`
+	Attempts    int       `json:"attempts,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Failed      bool      `json:"failed,omitempty"`
+	PostID      string    `json:"post_id,omitempty"`
+	// RetryAt is set when X turned the item away for its rate limit; the
+	// item is not tried again before then.
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+	OutgoingPost
+}
+
+const (
+	HistoryActionPost   = "post"
+	HistoryActionReply  = "reply"
+	HistoryActionQuote  = "quote"
+	HistoryActionDelete = "delete"
+)
+
+// HistoryRecord is one line of the local post history. RateLimit is the
+// rate-limit state X reported with the response, when it sent one.
+type HistoryRecord struct {
+	ID            string         `json:"id"`
+	Action        string         `json:"action"`
+	Profile       string         `json:"profile"`
+	Username      string         `json:"username,omitempty"`
+	Text          string         `json:"text,omitempty"`
+	ReplyTo       string         `json:"reply_to,omitempty"`
+	QuoteID       string         `json:"quote_id,omitempty"`
+	ReplySettings string         `json:"reply_settings,omitempty"`
+	CommunityID   string         `json:"community_id,omitempty"`
+	Time          time.Time      `json:"time"`
+	RateLimit     *RateLimitInfo `json:"rate_limit,omitempty"`
+}
+
+// SecretOverride is one line of the log of posts sent despite the secret
+// scanner. Matches are redacted.
+type SecretOverride struct {
+	Time    time.Time     `json:"time"`
+	Profile string        `json:"profile"`
+	Source  string        `json:"source"`
+	Matches []SecretMatch `json:"matches"`
+}
+
+type SecretMatch struct {
+	Kind     string `json:"kind"`
+	Redacted string `json:"redacted"`
+}
+
+const (
+	MastodonVisibilityPublic   = "public"
+	MastodonVisibilityUnlisted = "unlisted"
+	MastodonVisibilityPrivate  = "private"
+	MastodonVisibilityDirect   = "direct"
+)
+
+// MastodonApp is the OAuth application x-yapper registers once on each
+// Mastodon instance.
+type MastodonApp struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+type MastodonToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scope       string `json:"scope"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+type MastodonAccount struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+	URL         string `json:"url"`
+}
+
+// MastodonLogin is a profile's stored Mastodon sign-in. Mastodon tokens do
+// not expire, so there is nothing to refresh.
+type MastodonLogin struct {
+	Instance    string `json:"instance"`
+	AccountID   string `json:"account_id"`
+	Username    string `json:"username"`
+	AccessToken string `json:"access_token"`
+}
+
+// MastodonInstance holds the parts of an instance's configuration that
+// limit posts. Instances older than 3.4 only report MaxTootChars.
+type MastodonInstance struct {
+	Configuration struct {
+		Statuses struct {
+			MaxCharacters            int `json:"max_characters"`
+			MaxMediaAttachments      int `json:"max_media_attachments"`
+			CharactersReservedPerURL int `json:"characters_reserved_per_url"`
+		} `json:"statuses"`
+	} `json:"configuration"`
+	MaxTootChars int `json:"max_toot_chars,omitempty"`
+}
+
+// MastodonMedia is an uploaded attachment. URL stays empty while the
+// instance is still processing it.
+type MastodonMedia struct {
+	ID          string  `json:"id"`
+	Type        string  `json:"type"`
+	URL         *string `json:"url"`
+	Description string  `json:"description"`
+}
+
+type MastodonPoll struct {
+	Options   []string `json:"options"`
+	ExpiresIn int      `json:"expires_in"`
+}
+
+type MastodonStatusRequest struct {
+	Status      string        `json:"status"`
+	MediaIDs    []string      `json:"media_ids,omitempty"`
+	Poll        *MastodonPoll `json:"poll,omitempty"`
+	Visibility  string        `json:"visibility,omitempty"`
+	SpoilerText string        `json:"spoiler_text,omitempty"`
+	Sensitive   bool          `json:"sensitive,omitempty"`
+}
+
+type MastodonStatus struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Visibility string `json:"visibility"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type StoredToken struct {
+	UserID       string    `json:"user_id"`
+	Username     string    `json:"username"`
+	Verified     bool      `json:"verified"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type SinglePostResponse struct {
+	Data     Tweet `json:"data"`
+	Includes struct {
+		Users []User `json:"users,omitempty"`
+	} `json:"includes,omitempty"`
 }
 
 type TimelineResponse struct {
@@ -83,6 +456,58 @@ type User struct {
 	VerifiedType     string `json:"verified_type,omitempty"`
 }
 
+// UsersResponse is the answer to a users-by-username lookup. Usernames that
+// could not be returned, because they do not exist or are suspended, are
+// listed in Errors.
+type UsersResponse struct {
+	Data   []User          `json:"data,omitempty"`
+	Errors []ResourceError `json:"errors,omitempty"`
+}
+
+type ResourceError struct {
+	Value        string `json:"value"`
+	Detail       string `json:"detail"`
+	Title        string `json:"title"`
+	ResourceType string `json:"resource_type"`
+	Parameter    string `json:"parameter"`
+	ResourceID   string `json:"resource_id"`
+	Type         string `json:"type"`
+}
+
+// KnownHandle is an account seen in the timeline, a post lookup or a
+// mention check, kept for completion.
+type KnownHandle struct {
+	Username string    `json:"username"`
+	Name     string    `json:"name,omitempty"`
+	ID       string    `json:"id,omitempty"`
+	SeenAt   time.Time `json:"seen_at"`
+}
+
+// Community is an X Community. Access is "Public" or "Closed"; posting
+// needs membership either way.
+type Community struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Access      string     `json:"access,omitempty"`
+	JoinPolicy  string     `json:"join_policy,omitempty"`
+	MemberCount int        `json:"member_count,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+type CommunityResponse struct {
+	Data   Community       `json:"data"`
+	Errors []ResourceError `json:"errors,omitempty"`
+}
+
+type CommunitiesResponse struct {
+	Data []Community `json:"data"`
+	Meta struct {
+		ResultCount int    `json:"result_count"`
+		NextToken   string `json:"next_token,omitempty"`
+	} `json:"meta"`
+}
+
 type Tweet struct {
 	ID                  string            `json:"id"`
 	Text                string            `json:"text"`
@@ -103,7 +528,15 @@ type ReferencedTweet struct {
 }
 
 type Entities struct {
-	URLs []URL `json:"urls,omitempty"`
+	URLs     []URL     `json:"urls,omitempty"`
+	Mentions []Mention `json:"mentions,omitempty"`
+}
+
+type Mention struct {
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Username string `json:"username"`
+	ID       string `json:"id"`
 }
 
 type URL struct {
@@ -0,0 +1,185 @@
+package outbox
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"x-dev/internal/duplicates"
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+const outboxFile = "outbox.json"
+
+type box struct {
+	NextID int                  `json:"next_id"`
+	Items  []*models.OutboxItem `json:"items"`
+}
+
+var outboxMu sync.Mutex
+
+// Add queues item at the end of the outbox and sets its ID.
+func Add(item *models.OutboxItem) error {
+	return update(func(b *box) error {
+		b.NextID++
+
+		item.ID = strconv.Itoa(b.NextID)
+		item.QueuedAt = time.Now()
+
+		b.Items = append(b.Items, item)
+
+		return nil
+	})
+}
+
+// List returns the queued items in the order they were added.
+func List() ([]*models.OutboxItem, error) {
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+
+	b, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Items, nil
+}
+
+func Update(item *models.OutboxItem) error {
+	return update(func(b *box) error {
+		for i := range b.Items {
+			if b.Items[i].ID == item.ID {
+				b.Items[i] = item
+				return nil
+			}
+		}
+
+		return fmt.Errorf("no outbox item with ID %s", item.ID)
+	})
+}
+
+// Remove drops an item together with the queued replies that depend on it,
+// which could never be sent without it. It returns how many items were
+// removed.
+func Remove(id string) (int, error) {
+	removed := 0
+
+	err := update(func(b *box) error {
+		drop := map[string]bool{id: true}
+
+		for _, item := range b.Items {
+			if drop[item.ReplyToItem] {
+				drop[item.ID] = true
+			}
+		}
+
+		kept := b.Items[:0]
+
+		for _, item := range b.Items {
+			if drop[item.ID] {
+				removed++
+				continue
+			}
+
+			kept = append(kept, item)
+		}
+
+		if removed == 0 {
+			return fmt.Errorf("no outbox item with ID %s", id)
+		}
+
+		b.Items = kept
+
+		return nil
+	})
+
+	return removed, err
+}
+
+// Prune removes sent items that no queued reply still needs.
+func Prune() error {
+	return update(func(b *box) error {
+		needed := map[string]bool{}
+
+		for _, item := range b.Items {
+			if item.PostID == "" && item.ReplyToItem != "" {
+				needed[item.ReplyToItem] = true
+			}
+		}
+
+		kept := b.Items[:0]
+
+		for _, item := range b.Items {
+			if item.PostID == "" || needed[item.ID] {
+				kept = append(kept, item)
+			}
+		}
+
+		b.Items = kept
+
+		return nil
+	})
+}
+
+// FindSent looks for item among posts already on the account, because a
+// request that timed out may still have been published. posts already
+// matched to another item are skipped via used.
+func FindSent(item *models.OutboxItem, posts []models.Tweet, used map[string]bool) (string, bool) {
+	want := duplicates.Normalize(item.Text)
+
+	for _, post := range posts {
+		if used[post.ID] || duplicates.Normalize(duplicates.TweetText(post)) != want {
+			continue
+		}
+
+		if item.ReplyTo != "" && !references(post, "replied_to", item.ReplyTo) {
+			continue
+		}
+
+		if item.QuoteID != "" && !references(post, "quoted", item.QuoteID) {
+			continue
+		}
+
+		return post.ID, true
+	}
+
+	return "", false
+}
+
+func references(post models.Tweet, kind string, id string) bool {
+	for _, ref := range post.ReferencedTweets {
+		if ref.Type == kind && ref.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func update(change func(*box) error) error {
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+
+	b, err := load()
+	if err != nil {
+		return err
+	}
+
+	if err := change(b); err != nil {
+		return err
+	}
+
+	return store.Save(outboxFile, b)
+}
+
+func load() (*box, error) {
+	b := &box{}
+
+	if _, err := store.Load(outboxFile, b); err != nil {
+		return nil, fmt.Errorf("failed to load outbox: %w", err)
+	}
+
+	return b, nil
+}
@@ -0,0 +1,121 @@
+package poll
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"x-dev/internal/models"
+)
+
+const (
+	MinOptions         = 2
+	MaxOptions         = 4
+	MaxOptionLength    = 25
+	MinDurationMinutes = 5
+	MaxDurationMinutes = 7 * 24 * 60
+)
+
+func New(options []string, duration string) (*models.Poll, error) {
+	minutes, err := ParseDuration(duration)
+	if err != nil {
+		return nil, err
+	}
+
+	poll := &models.Poll{DurationMinutes: minutes}
+
+	for _, option := range options {
+		poll.Options = append(poll.Options, strings.TrimSpace(option))
+	}
+
+	if err := Validate(poll); err != nil {
+		return nil, err
+	}
+
+	return poll, nil
+}
+
+func Validate(poll *models.Poll) error {
+	if len(poll.Options) < MinOptions || len(poll.Options) > MaxOptions {
+		return fmt.Errorf("a poll needs between %d and %d options, got %d", MinOptions, MaxOptions, len(poll.Options))
+	}
+
+	seen := make(map[string]bool, len(poll.Options))
+
+	for i, option := range poll.Options {
+		if option == "" {
+			return fmt.Errorf("poll option %d is empty", i+1)
+		}
+
+		if utf8.RuneCountInString(option) > MaxOptionLength {
+			return fmt.Errorf("poll option %q is longer than %d characters", option, MaxOptionLength)
+		}
+
+		key := strings.ToLower(option)
+		if seen[key] {
+			return fmt.Errorf("poll option %q is listed twice", option)
+		}
+
+		seen[key] = true
+	}
+
+	if poll.DurationMinutes < MinDurationMinutes || poll.DurationMinutes > MaxDurationMinutes {
+		return fmt.Errorf("poll duration must be between %d minutes and %d days", MinDurationMinutes, MaxDurationMinutes/(24*60))
+	}
+
+	return nil
+}
+
+// ParseDuration accepts a bare number of minutes, a Go duration such as
+// "1h30m", or a number of days such as "3d".
+func ParseDuration(value string) (int, error) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	if value == "" {
+		return 0, errors.New("poll duration is required")
+	}
+
+	if minutes, err := strconv.Atoi(value); err == nil {
+		return minutes, nil
+	}
+
+	if days, found := strings.CutSuffix(value, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid poll duration %q", value)
+		}
+
+		return count * 24 * 60, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid poll duration %q", value)
+	}
+
+	return int(duration.Minutes()), nil
+}
+
+func FormatDuration(minutes int) string {
+	days := minutes / (24 * 60)
+	hours := minutes % (24 * 60) / 60
+	mins := minutes % 60
+
+	var parts []string
+
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+
+	if mins > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%dm", mins))
+	}
+
+	return strings.Join(parts, " ")
+}
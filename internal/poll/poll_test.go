@@ -0,0 +1,89 @@
+package poll
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]int{
+		"30":     30,
+		" 45 ":   45,
+		"30m":    30,
+		"6h":     360,
+		"1h30m":  90,
+		"3d":     3 * 24 * 60,
+		"1D":     24 * 60,
+		"7d":     MaxDurationMinutes,
+		"90s":    1,
+		"2h0m0s": 120,
+		// Out of range values parse; Validate rejects them.
+		"0":  0,
+		"8d": 8 * 24 * 60,
+	}
+
+	for input, want := range tests {
+		if got, err := ParseDuration(input); err != nil || got != want {
+			t.Errorf("ParseDuration(%q) = %d, %v, want %d", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "  ", "soon", "d", "1.5d", "3 days", "1w"} {
+		if got, err := ParseDuration(input); err == nil {
+			t.Errorf("ParseDuration(%q) = %d, want an error", input, got)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	poll, err := New([]string{" Yes ", "No"}, "1d")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(poll.Options, "|") != "Yes|No" || poll.DurationMinutes != 24*60 {
+		t.Errorf("New = %+v", poll)
+	}
+
+	tests := []struct {
+		options  []string
+		duration string
+	}{
+		{[]string{"Only one"}, "1d"},
+		{[]string{"a", "b", "c", "d", "e"}, "1d"},
+		{[]string{"Yes", " "}, "1d"},
+		{[]string{"Yes", "yes"}, "1d"},
+		{[]string{"Yes", strings.Repeat("n", MaxOptionLength+1)}, "1d"},
+		{[]string{"Yes", "No"}, "4m"},
+		{[]string{"Yes", "No"}, "8d"},
+		{[]string{"Yes", "No"}, "later"},
+	}
+
+	for _, test := range tests {
+		if _, err := New(test.options, test.duration); err == nil {
+			t.Errorf("New(%q, %q) succeeded", test.options, test.duration)
+		}
+	}
+
+	// The limit counts characters, not bytes.
+	if _, err := New([]string{strings.Repeat("é", MaxOptionLength), "No"}, "5m"); err != nil {
+		t.Errorf("New with a %d character option failed: %v", MaxOptionLength, err)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[int]string{
+		0:                  "0m",
+		5:                  "5m",
+		90:                 "1h 30m",
+		24 * 60:            "1d",
+		3*24*60 + 60 + 1:   "3d 1h 1m",
+		MaxDurationMinutes: "7d",
+	}
+
+	for minutes, want := range tests {
+		if got := FormatDuration(minutes); got != want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", minutes, got, want)
+		}
+	}
+}
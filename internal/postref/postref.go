@@ -0,0 +1,88 @@
+package postref
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	postIDPattern = regexp.MustCompile(`^[0-9]{1,19}$`)
+	statusPath    = regexp.MustCompile(`^/(?:[A-Za-z0-9_]{1,15}|i/web)/status(?:es)?/([0-9]{1,19})(?:/.*)?$`)
+	communityPath = regexp.MustCompile(`^/i/communities/([0-9]{1,19})(?:/.*)?$`)
+	postHosts     = map[string]bool{
+		"x.com":              true,
+		"www.x.com":          true,
+		"mobile.x.com":       true,
+		"twitter.com":        true,
+		"www.twitter.com":    true,
+		"mobile.twitter.com": true,
+	}
+)
+
+// ParseID accepts a bare post ID or an x.com/twitter.com status URL and
+// returns the post ID.
+func ParseID(input string) (string, error) {
+	input = strings.TrimSpace(input)
+
+	if postIDPattern.MatchString(input) {
+		return input, nil
+	}
+
+	rawURL := input
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !postHosts[strings.ToLower(u.Host)] {
+		return "", fmt.Errorf("%q is not a post ID or x.com status URL", input)
+	}
+
+	match := statusPath.FindStringSubmatch(u.Path)
+	if match == nil {
+		return "", fmt.Errorf("%q is not a post ID or x.com status URL", input)
+	}
+
+	return match[1], nil
+}
+
+func Permalink(username string, postID string) string {
+	if username == "" {
+		username = "i/web"
+	}
+
+	return fmt.Sprintf("https://x.com/%s/status/%s", username, postID)
+}
+
+// ParseCommunityID accepts a bare community ID or an x.com/i/communities
+// URL and returns the community ID.
+func ParseCommunityID(input string) (string, error) {
+	input = strings.TrimSpace(input)
+
+	if postIDPattern.MatchString(input) {
+		return input, nil
+	}
+
+	rawURL := input
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !postHosts[strings.ToLower(u.Host)] {
+		return "", fmt.Errorf("%q is not a community ID or x.com community URL", input)
+	}
+
+	match := communityPath.FindStringSubmatch(u.Path)
+	if match == nil {
+		return "", fmt.Errorf("%q is not a community ID or x.com community URL", input)
+	}
+
+	return match[1], nil
+}
+
+func CommunityLink(communityID string) string {
+	return "https://x.com/i/communities/" + communityID
+}
@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"fmt"
+
+	"x-dev/internal/communities"
+	"x-dev/internal/models"
+
+	"github.com/manifoldco/promptui"
+)
+
+const timelineLabel = "Your timeline"
+
+// canPostToCommunity reports whether the draft may go to a community. X
+// only takes community_id on new posts, not on replies or quotes.
+func (d *postDraft) canPostToCommunity() bool {
+	return d.replyToID == "" && d.replyToQueued == "" && d.quoteID == ""
+}
+
+// communityLabel names where the draft will be posted.
+func (d *postDraft) communityLabel() string {
+	if d.community == nil {
+		return timelineLabel
+	}
+
+	return communities.Label(*d.community)
+}
+
+// promptCommunity picks where to post: the timeline, or one of the
+// profile's communities. nil means the timeline.
+func promptCommunity(current *models.Community, joined []models.Community) (*models.Community, error) {
+	labels := []string{timelineLabel}
+	cursor := 0
+
+	for i, community := range joined {
+		labels = append(labels, communities.Label(community))
+
+		if current != nil && current.ID == community.ID {
+			cursor = i + 1
+		}
+	}
+
+	communityPrompt := promptui.Select{
+		Label:     "Post to",
+		Items:     labels,
+		CursorPos: cursor,
+	}
+
+	index, _, err := communityPrompt.Run()
+	if err != nil {
+		return current, fmt.Errorf("community selection failed: %w", err)
+	}
+
+	if index == 0 {
+		return nil, nil
+	}
+
+	return &joined[index-1], nil
+}
+
+// findCommunity returns the joined community with id, or one known only by
+// its ID when the profile does not list it.
+func findCommunity(id string, joined []models.Community) *models.Community {
+	if id == "" {
+		return nil
+	}
+
+	for i := range joined {
+		if joined[i].ID == id {
+			return &joined[i]
+		}
+	}
+
+	return &models.Community{ID: id}
+}
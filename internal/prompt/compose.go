@@ -0,0 +1,405 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/drafts"
+	"x-dev/internal/mastodon"
+	"x-dev/internal/media"
+	"x-dev/internal/models"
+	"x-dev/internal/poll"
+	"x-dev/internal/twittertext"
+
+	"github.com/manifoldco/promptui"
+)
+
+type postDraft struct {
+	text                string
+	attachments         []*models.MediaAttachment
+	poll                *models.Poll
+	replyToID           string
+	replyToQueued       string
+	quoteID             string
+	target              *targetPost
+	excludeReplyUserIDs []string
+	replySettings       string
+	// replySettingsChosen is set once the user picks who can reply in
+	// the preview, rather than keeping the profile's default.
+	replySettingsChosen bool
+	allowSecrets        bool
+	community           *models.Community
+	// mastodon is nil when the draft does not also go to Mastodon.
+	mastodon *mastodon.Options
+	stored   *models.Draft
+}
+
+func (d *postDraft) isEmpty() bool {
+	return d.text == "" && len(d.attachments) == 0
+}
+
+func (d *postDraft) post() *models.Post {
+	return &models.Post{
+		Text:          d.text,
+		Media:         media.MediaIDs(d.attachments),
+		Poll:          d.poll,
+		ReplySettings: d.replySettings,
+		CommunityID:   d.communityID(),
+	}
+}
+
+func (d *postDraft) replyPost() *models.ReplyPost {
+	replyPost := &models.ReplyPost{
+		Text:          d.text,
+		Media:         media.MediaIDs(d.attachments),
+		QuoteTweetID:  d.quoteID,
+		ReplySettings: d.replySettings,
+	}
+
+	if d.replyToID != "" {
+		replyPost.Reply = &models.Reply{
+			ReplyID:             d.replyToID,
+			ExcludeReplyUserIDs: d.excludeReplyUserIDs,
+		}
+	}
+
+	return replyPost
+}
+
+// outgoing describes the draft for the schedule queue, which uploads the
+// attachments again from their paths when the post is due.
+func (d *postDraft) outgoing() models.OutgoingPost {
+	out := models.OutgoingPost{
+		Text:                d.text,
+		Poll:                d.poll,
+		ReplyTo:             d.replyToID,
+		ExcludeReplyUserIDs: d.excludeReplyUserIDs,
+		QuoteID:             d.quoteID,
+		ReplySettings:       d.replySettings,
+		CommunityID:         d.communityID(),
+		AllowSecrets:        d.allowSecrets,
+	}
+
+	for _, attachment := range d.attachments {
+		out.MediaPaths = append(out.MediaPaths, attachment.Path)
+		out.AltTexts = append(out.AltTexts, attachment.AltText)
+	}
+
+	return out
+}
+
+func (d *postDraft) communityID() string {
+	if d.community == nil {
+		return ""
+	}
+
+	return d.community.ID
+}
+
+// canRestrictReplies reports whether the draft starts a conversation of its
+// own. Replies inherit the conversation's settings, so only new posts and
+// quotes get a choice.
+func (d *postDraft) canRestrictReplies() bool {
+	return d.replyToID == "" && d.replyToQueued == ""
+}
+
+func (d *postDraft) checkLength(maxPostLength int) error {
+	if twittertext.WeightedLength(d.text) > maxPostLength {
+		return fmt.Errorf("post exceeds maximum length of %d characters", maxPostLength)
+	}
+
+	return nil
+}
+
+func composePost(ctx context.Context, editor *config.Editor, stored *models.Draft, mediaPaths []string, limits models.MediaLimits) (*postDraft, error) {
+	content, err := editDraft(ctx, editor, stored, media.FormatAttachHeader(mediaPaths))
+	if err != nil {
+		return nil, err
+	}
+
+	content, headerPaths := media.ParseAttachHeader(content)
+	content = strings.TrimSpace(content)
+
+	paths := append(append([]string{}, mediaPaths...), headerPaths...)
+
+	attachments, err := media.InspectAll(paths, limits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attachment: %w", err)
+	}
+
+	if err := promptAltText(attachments); err != nil {
+		return nil, err
+	}
+
+	return &postDraft{text: content, attachments: attachments, stored: stored}, nil
+}
+
+// editDraft opens the newest version of stored in the editor and autosaves
+// the result, so nothing typed is lost if the post is never sent. prefix is
+// saved with the content but not shown in the editor.
+func editDraft(ctx context.Context, editor *config.Editor, stored *models.Draft, prefix string) (string, error) {
+	content, err := editor.EditContent(ctx, drafts.Latest(stored))
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(content) != "" {
+		if err := drafts.Record(stored, prefix+content); err != nil {
+			fmt.Println(Warn("[WARN] "), "could not autosave draft:", err)
+		}
+	}
+
+	return content, nil
+}
+
+func saveDraft(draft *postDraft) {
+	draft.stored.Saved = true
+
+	if err := drafts.Save(draft.stored); err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+		return
+	}
+
+	fmt.Println("\U0001F4BE Draft saved.")
+}
+
+func forgetDraft(id string) {
+	if err := drafts.Remove(id); err != nil {
+		fmt.Println(Warn("[WARN] "), "could not remove sent draft:", err)
+	}
+}
+
+func composePoll(ctx context.Context, editor *config.Editor, stored *models.Draft, maxPostLength int) (*postDraft, error) {
+	fmt.Println(Info("[INFO] "), "write the poll question in the editor")
+
+	content, err := editDraft(ctx, editor, stored, "")
+	if err != nil {
+		return nil, err
+	}
+
+	content = strings.TrimSpace(content)
+
+	if content == "" {
+		return nil, errors.New("a poll needs a question")
+	}
+
+	if twittertext.WeightedLength(content) > maxPostLength {
+		return nil, fmt.Errorf("post exceeds maximum length of %d characters", maxPostLength)
+	}
+
+	var options []string
+
+	for len(options) < poll.MaxOptions {
+		label := fmt.Sprintf("Poll option %d", len(options)+1)
+		if len(options) >= poll.MinOptions {
+			label += " (enter to finish)"
+		}
+
+		optionPrompt := promptui.Prompt{
+			Label: label,
+			Validate: func(input string) error {
+				input = strings.TrimSpace(input)
+
+				if input == "" && len(options) < poll.MinOptions {
+					return fmt.Errorf("at least %d options are required", poll.MinOptions)
+				}
+
+				if utf8.RuneCountInString(input) > poll.MaxOptionLength {
+					return fmt.Errorf("options are limited to %d characters", poll.MaxOptionLength)
+				}
+
+				return nil
+			},
+		}
+
+		option, err := optionPrompt.Run()
+		if err != nil {
+			return nil, fmt.Errorf("poll option prompt failed: %w", err)
+		}
+
+		option = strings.TrimSpace(option)
+		if option == "" {
+			break
+		}
+
+		options = append(options, option)
+	}
+
+	durationPrompt := promptui.Prompt{
+		Label:   "Poll duration (e.g. 30m, 6h, 1d, up to 7d)",
+		Default: "1d",
+		Validate: func(input string) error {
+			minutes, err := poll.ParseDuration(input)
+			if err != nil {
+				return err
+			}
+
+			if minutes < poll.MinDurationMinutes || minutes > poll.MaxDurationMinutes {
+				return errors.New("duration must be between 5 minutes and 7 days")
+			}
+
+			return nil
+		},
+	}
+
+	duration, err := durationPrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("poll duration prompt failed: %w", err)
+	}
+
+	newPoll, err := poll.New(options, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postDraft{text: content, poll: newPoll, stored: stored}, nil
+}
+
+func sendNewPost(ctx context.Context, draft *postDraft, accessToken string, opts Options, latestPost *models.LatestPost) string {
+	if err := uploadAttachments(ctx, draft.attachments, accessToken, opts.Upload); err != nil {
+		if api.IsUnavailable(err) {
+			queueDraft(draft, opts, latestPost, err)
+		} else {
+			fmt.Println(Failed("[ERROR] "), err)
+		}
+
+		return ""
+	}
+
+	var postID string
+
+	postResponse, rateLimit, err := api.SendPost(ctx, draft.post(), accessToken)
+	if api.IsUnavailable(err) {
+		queueDraft(draft, opts, latestPost, err)
+	} else if err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+	} else {
+		postID = postResponse.Data.ID
+		fmt.Println("\U00002705 Post Successful! Post ID: ", postID)
+		recordHistory(opts, draft.historyRecord(postID, rateLimit))
+		latestPost.PostID = postID
+		latestPost.OutboxID = ""
+		latestPost.Text = draft.text
+
+		if draft.stored != nil {
+			forgetDraft(draft.stored.ID)
+		}
+	}
+
+	rateLimitStatus := rateLimitStatus(rateLimit)
+
+	if rateLimitStatus != "" {
+		fmt.Println(rateLimitStatus)
+	}
+
+	return postID
+}
+
+func sendReplyPost(ctx context.Context, draft *postDraft, accessToken string, opts Options, latestPost *models.LatestPost, successMessage string) {
+	// The parent is still in the outbox, so the reply has to wait there too.
+	if draft.replyToQueued != "" {
+		queueDraft(draft, opts, latestPost, nil)
+		return
+	}
+
+	if err := uploadAttachments(ctx, draft.attachments, accessToken, opts.Upload); err != nil {
+		if api.IsUnavailable(err) {
+			queueDraft(draft, opts, latestPost, err)
+		} else {
+			fmt.Println(Failed("[ERROR] "), err)
+		}
+
+		return
+	}
+
+	postResponse, rateLimit, err := api.SendReplyPost(ctx, draft.replyPost(), accessToken)
+	if api.IsUnavailable(err) {
+		queueDraft(draft, opts, latestPost, err)
+	} else if err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+	} else {
+		postID := postResponse.Data.ID
+		fmt.Println("\U00002705", successMessage, "Post ID: ", postID)
+		recordHistory(opts, draft.historyRecord(postID, rateLimit))
+		latestPost.PostID = postID
+		latestPost.OutboxID = ""
+		latestPost.Text = draft.text
+
+		if draft.stored != nil {
+			forgetDraft(draft.stored.ID)
+		}
+	}
+
+	rateLimitStatus := rateLimitStatus(rateLimit)
+
+	if rateLimitStatus != "" {
+		fmt.Println(rateLimitStatus)
+	}
+}
+
+func promptAltText(attachments []*models.MediaAttachment) error {
+	for _, attachment := range attachments {
+		if attachment.Category == models.MediaCategoryVideo {
+			continue
+		}
+
+		altPrompt := promptui.Prompt{
+			Label:   fmt.Sprintf("Alt text for %s (enter to skip)", filepath.Base(attachment.Path)),
+			Default: attachment.AltText,
+			Validate: func(input string) error {
+				if len([]rune(input)) > models.MaxAltTextLength {
+					return fmt.Errorf("alt text is limited to %d characters", models.MaxAltTextLength)
+				}
+
+				return nil
+			},
+		}
+
+		altText, err := altPrompt.Run()
+		if err != nil {
+			return fmt.Errorf("alt text prompt failed: %w", err)
+		}
+
+		attachment.AltText = strings.TrimSpace(altText)
+	}
+
+	return nil
+}
+
+func uploadAttachments(ctx context.Context, attachments []*models.MediaAttachment, accessToken string, opts media.UploadOptions) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	fmt.Println(Info("[INFO] "), "uploading", len(attachments), "attachment(s)")
+
+	opts.Progress = PrintUploadProgress
+
+	err := media.UploadAll(ctx, attachments, accessToken, opts)
+
+	fmt.Println()
+
+	if err != nil {
+		return fmt.Errorf("media upload failed: %w", err)
+	}
+
+	return nil
+}
+
+// PrintUploadProgress redraws a single progress bar line for a media
+// upload. The caller ends the line once the upload is done.
+func PrintUploadProgress(name string, stage string, percent int) {
+	const barWidth = 30
+
+	filled := percent * barWidth / 100
+
+	fmt.Printf("\r\033[K%s %-10s %s [%s%s] %3d%%",
+		Info("[INFO] "), stage, name,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled), percent)
+}
@@ -0,0 +1,174 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"x-dev/internal/crosspost"
+	"x-dev/internal/postref"
+	"x-dev/internal/twittertext"
+
+	"github.com/manifoldco/promptui"
+)
+
+// canCrossPost reports whether the draft can be sent from other profiles
+// too: replies belong to one conversation and communities to one account.
+// Quotes can go out from every profile, as any account can quote a public
+// post.
+func (d *postDraft) canCrossPost() bool {
+	return d.replyToID == "" && d.replyToQueued == "" && d.community == nil
+}
+
+// crossPostDraft sends the draft from the profiles the user picks, each
+// with its own variables and link rules. It reports whether the post went
+// out, or waits in the outbox, for at least one of them.
+func crossPostDraft(ctx context.Context, draft *postDraft, opts Options) (bool, error) {
+	profiles, err := chooseProfiles(opts.CrossPost.Profiles(), opts.Profile)
+	if err != nil || len(profiles) == 0 {
+		return false, err
+	}
+
+	// Without a choice in the preview each profile keeps its own default.
+	out := draft.outgoing()
+	out.ReplySettings = ""
+
+	targets, err := opts.CrossPost.Prepare(profiles, out, nil)
+	if err != nil {
+		return false, err
+	}
+
+	blocked := false
+
+	for i := range targets {
+		target := &targets[i]
+
+		if draft.replySettingsChosen {
+			target.Post.ReplySettings = draft.replySettings
+		}
+
+		fmt.Printf("\n%s (%d characters):\n", target.Profile, twittertext.WeightedLength(target.Post.Text))
+		fmt.Println("------------------------------------------------------------")
+		fmt.Println(wrapText(target.Post.Text, 60))
+		fmt.Println("------------------------------------------------------------")
+
+		printLinkChanges(target.Links, target.Post.Text)
+
+		findings, err := opts.CrossPost.Lint(*target, draft.attachments)
+		if err != nil {
+			return false, err
+		}
+
+		if PrintFindings(findings) {
+			blocked = true
+		}
+	}
+
+	if blocked {
+		fmt.Println(Failed("[ERROR] "), "nothing sent, fix the lint errors above or leave those profiles out")
+		return false, nil
+	}
+
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Send to %d profile(s)", len(targets)),
+		IsConfirm: true,
+	}
+
+	if _, err := confirmPrompt.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("cross-post confirmation failed: %w", err)
+	}
+
+	results := opts.CrossPost.Send(ctx, targets)
+	PrintCrossPostSummary(results)
+
+	return crosspost.Failed(results) < len(results), nil
+}
+
+// chooseProfiles toggles profiles on and off until the user continues.
+// All of them start selected.
+func chooseProfiles(profiles []string, current string) ([]string, error) {
+	const (
+		continueLabel = "Continue"
+		cancelLabel   = "Cancel"
+	)
+
+	if !slices.Contains(profiles, current) {
+		profiles = append([]string{current}, profiles...)
+	}
+
+	selected := map[string]bool{}
+	for _, profile := range profiles {
+		selected[profile] = true
+	}
+
+	cursor := 0
+
+	for {
+		items := make([]string, 0, len(profiles)+2)
+
+		for _, profile := range profiles {
+			mark := "[ ]"
+			if selected[profile] {
+				mark = "[x]"
+			}
+
+			items = append(items, mark+" "+profile)
+		}
+
+		items = append(items, continueLabel, cancelLabel)
+
+		profilePrompt := promptui.Select{
+			Label:     "Post from which profiles",
+			Items:     items,
+			CursorPos: cursor,
+		}
+
+		index, choice, err := profilePrompt.Run()
+		if err != nil {
+			return nil, fmt.Errorf("profile selection failed: %w", err)
+		}
+
+		switch choice {
+		case continueLabel:
+			var chosen []string
+
+			for _, profile := range profiles {
+				if selected[profile] {
+					chosen = append(chosen, profile)
+				}
+			}
+
+			return chosen, nil
+
+		case cancelLabel:
+			return nil, nil
+		}
+
+		selected[profiles[index]] = !selected[profiles[index]]
+		cursor = index
+	}
+}
+
+// PrintCrossPostSummary reports how sending to each profile went.
+func PrintCrossPostSummary(results []crosspost.Result) {
+	fmt.Println("\nCross-post summary:")
+	fmt.Println("------------------------------------------------------------")
+
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			fmt.Println(Failed("[ERROR] "), result.Profile+":", result.Err)
+		case result.OutboxID != "":
+			fmt.Println(Warn("[WARN] "), result.Profile+":", fmt.Sprintf("X is unreachable, saved to the outbox as item %s", result.OutboxID))
+		default:
+			fmt.Println(Success("[OK] "), result.Profile+":", postref.Permalink(result.Username, result.PostID))
+		}
+	}
+
+	fmt.Println("------------------------------------------------------------")
+}
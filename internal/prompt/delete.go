@@ -0,0 +1,218 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/drafts"
+	"x-dev/internal/models"
+
+	"github.com/eiannone/keyboard"
+	"github.com/manifoldco/promptui"
+)
+
+func ConfirmDelete(postID string) (bool, error) {
+	confirmPrompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Delete post %s? This cannot be undone", postID),
+		IsConfirm: true,
+	}
+
+	if _, err := confirmPrompt.Run(); err != nil {
+		if errors.Is(err, promptui.ErrAbort) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("delete confirmation failed: %w", err)
+	}
+
+	return true, nil
+}
+
+func deletePost(ctx context.Context, userID string, accessToken string, opts Options, latestPost *models.LatestPost) {
+	target, err := promptTargetPost(ctx, accessToken)
+	if err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+		return
+	}
+
+	if target.tweet.AuthorID != userID {
+		fmt.Println(Failed("[ERROR] "), "you can only delete your own posts.")
+		return
+	}
+
+	confirmed, err := ConfirmDelete(target.tweet.ID)
+	if err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+		return
+	}
+
+	if !confirmed {
+		fmt.Println(Info("[INFO] "), "post kept.")
+		return
+	}
+
+	rateLimit, err := api.DeletePost(ctx, target.tweet.ID, accessToken)
+	if err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+	} else {
+		fmt.Println("\U0001F5D1 Post deleted. Post ID: ", target.tweet.ID)
+		recordHistory(opts, models.HistoryRecord{
+			ID:        target.tweet.ID,
+			Action:    models.HistoryActionDelete,
+			Text:      target.tweet.Text,
+			RateLimit: rateLimit,
+		})
+
+		if latestPost.PostID == target.tweet.ID {
+			latestPost.PostID = ""
+			latestPost.Text = ""
+		}
+	}
+
+	if rateLimitStatus := rateLimitStatus(rateLimit); rateLimitStatus != "" {
+		fmt.Println(rateLimitStatus)
+	}
+}
+
+// sendWithUndo sends a new post and, when an undo window is configured,
+// gives the user a few seconds to take it back. An undone post is deleted
+// and its text reopened in the editor so it can be fixed and sent again.
+func sendWithUndo(ctx context.Context, editor *config.Editor, draft *postDraft, maxPostLength int, accessToken string, opts Options, latestPost *models.LatestPost) {
+	for {
+		previous := *latestPost
+
+		postID := sendNewPost(ctx, draft, accessToken, opts, latestPost)
+		if postID == "" {
+			if draft.mastodon != nil {
+				fmt.Println(Info("[INFO] "), "not posted to Mastodon either, as the post did not go out on X")
+			}
+
+			return
+		}
+
+		// The Mastodon copy waits for the undo window, so undoing never has
+		// to delete it from a second network.
+		if opts.UndoSeconds <= 0 {
+			sendToMastodon(ctx, draft, opts, postID)
+			return
+		}
+
+		undo, err := waitForUndo(ctx, opts.UndoSeconds)
+		if err != nil {
+			fmt.Println(Warn("[WARN] "), err)
+
+			if ctx.Err() == nil {
+				sendToMastodon(ctx, draft, opts, postID)
+			}
+
+			return
+		}
+
+		if !undo {
+			sendToMastodon(ctx, draft, opts, postID)
+			return
+		}
+
+		rateLimit, err := api.DeletePost(ctx, postID, accessToken)
+		if err != nil {
+			fmt.Println(Failed("[ERROR] "), "undo failed, the post is still live:", err)
+			return
+		}
+
+		recordHistory(opts, models.HistoryRecord{
+			ID:        postID,
+			Action:    models.HistoryActionDelete,
+			Text:      draft.text,
+			RateLimit: rateLimit,
+		})
+
+		*latestPost = previous
+
+		fmt.Println("\U000021A9 Post deleted, reopening it in the editor.")
+
+		content, err := editor.EditContent(ctx, draft.text)
+		if err != nil {
+			fmt.Println(Failed("[ERROR] "), err)
+			return
+		}
+
+		draft.text = strings.TrimSpace(content)
+		draft.allowSecrets = false
+
+		if err := drafts.Record(draft.stored, content); err != nil {
+			fmt.Println(Warn("[WARN] "), "could not autosave draft:", err)
+		}
+
+		if draft.isEmpty() {
+			fmt.Println("\U0000274C Post discarded.")
+			return
+		}
+
+		if err := draft.checkLength(maxPostLength); err != nil {
+			fmt.Println(Failed("[ERROR] "), err)
+			return
+		}
+
+		previewResponse, err := showPreviewPrompt(draft, maxPostLength, opts)
+		if err != nil {
+			fmt.Println(Failed("[ERROR] "), err)
+			return
+		}
+
+		switch previewResponse {
+		case 0:
+			continue
+		case 2:
+			saveDraft(draft)
+		case 3:
+			scheduleDraft(draft, opts)
+		case 4:
+		default:
+			fmt.Println("\U0000274C Post discarded.")
+		}
+
+		return
+	}
+}
+
+func waitForUndo(ctx context.Context, seconds int) (bool, error) {
+	keys, err := keyboard.GetKeys(1)
+	if err != nil {
+		return false, fmt.Errorf("could not open keyboard: %w", err)
+	}
+	defer keyboard.Close()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for remaining := seconds; remaining > 0; {
+		fmt.Printf("\r\033[K%s press u to undo, any other key to keep the post (%ds)", Info("[INFO] "), remaining)
+
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			return false, ctx.Err()
+
+		case event := <-keys:
+			fmt.Println()
+
+			if event.Err != nil {
+				return false, fmt.Errorf("error reading keyboard: %w", event.Err)
+			}
+
+			return event.Rune == 'u' || event.Rune == 'U', nil
+
+		case <-ticker.C:
+			remaining--
+		}
+	}
+
+	fmt.Println()
+
+	return false, nil
+}
@@ -0,0 +1,123 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"x-dev/internal/drafts"
+	"x-dev/internal/models"
+
+	"github.com/manifoldco/promptui"
+)
+
+// promptDrafts lets the user browse stored drafts. It returns the draft to
+// resume, or nil when the user went back or deleted one.
+func promptDrafts() (*models.Draft, error) {
+	stored, err := drafts.List()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stored) == 0 {
+		fmt.Println(Info("[INFO] "), "no drafts.")
+		return nil, nil
+	}
+
+	items := make([]string, 0, len(stored))
+
+	for _, draft := range stored {
+		state := "autosave"
+		if draft.Saved {
+			state = "saved"
+		}
+
+		items = append(items, fmt.Sprintf("%s  %-6s %-8s v%d  %s",
+			draft.UpdatedAt.Format("Jan 2 15:04"), draft.Kind, state, len(draft.Versions),
+			truncate(strings.ReplaceAll(drafts.Latest(draft), "\n", " "), 40)))
+	}
+
+	draftPrompt := promptui.Select{
+		Label: "Choose a draft",
+		Items: items,
+	}
+
+	index, _, err := draftPrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("draft selection failed: %w", err)
+	}
+
+	selected := stored[index]
+
+	for {
+		actions := []string{"Resume", "Preview"}
+		if len(selected.Versions) > 1 {
+			actions = append(actions, "Show changes between versions")
+		}
+
+		actions = append(actions, "Delete", "Back")
+
+		actionPrompt := promptui.Select{
+			Label: "Choose an action",
+			Items: actions,
+		}
+
+		_, action, err := actionPrompt.Run()
+		if err != nil {
+			return nil, fmt.Errorf("draft action failed: %w", err)
+		}
+
+		switch action {
+		case "Resume":
+			return selected, nil
+
+		case "Preview":
+			fmt.Printf("\nDraft Preview (%s, %d version(s)):\n", selected.Kind, len(selected.Versions))
+			fmt.Println("------------------------------------------------------------")
+			fmt.Println(wrapText(drafts.Latest(selected), 60))
+			fmt.Println("------------------------------------------------------------")
+
+		case "Show changes between versions":
+			printDraftVersions(selected)
+
+		case "Delete":
+			if err := drafts.Remove(selected.ID); err != nil {
+				return nil, err
+			}
+
+			fmt.Println("\U0000274C Draft deleted.")
+
+			return nil, nil
+
+		default:
+			return nil, nil
+		}
+	}
+}
+
+func printDraftVersions(draft *models.Draft) {
+	fmt.Println()
+
+	for i := 1; i < len(draft.Versions); i++ {
+		before := draft.Versions[i-1]
+		after := draft.Versions[i]
+
+		fmt.Printf("v%d -> v%d (%s)\n", i, i+1, after.SavedAt.Format("Jan 2 15:04:05"))
+		fmt.Println("------------------------------------------------------------")
+
+		var diff strings.Builder
+
+		for _, op := range drafts.Diff(before.Text, after.Text) {
+			switch op.Kind {
+			case drafts.DiffDelete:
+				diff.WriteString(Failed("[-" + op.Text + "-]"))
+			case drafts.DiffInsert:
+				diff.WriteString(Success("{+" + op.Text + "+}"))
+			default:
+				diff.WriteString(op.Text)
+			}
+		}
+
+		fmt.Println(diff.String())
+		fmt.Println("------------------------------------------------------------")
+	}
+}
@@ -0,0 +1,174 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/drafts"
+	"x-dev/internal/duplicates"
+	"x-dev/internal/history"
+	"x-dev/internal/mentions"
+	"x-dev/internal/models"
+
+	"github.com/dustin/go-humanize"
+)
+
+// accountPostsTTL is how long posts fetched from X are reused between
+// previews. Posts sent meanwhile are in the local history anyway.
+const accountPostsTTL = 5 * time.Minute
+
+// previewContext carries what the preview needs beyond the draft: the
+// earlier posts to compare it with, a way to check mentions and a way back
+// into the editor.
+type previewContext struct {
+	recent  func() []duplicates.Candidate
+	resolve func(usernames []string) map[string]mentions.Result
+	edit    func(text string) (string, error)
+	// crossPost is set when other profiles are configured to post from.
+	crossPost func(draft *postDraft) (bool, error)
+}
+
+// newPreviewContext prepares the duplicate and mention checks for a
+// session. Without an access token only the local history is searched and
+// mentions are not checked.
+func newPreviewContext(ctx context.Context, editor *config.Editor, opts Options, accessToken string, userID string) *previewContext {
+	var (
+		fetched   []duplicates.Candidate
+		fetchedAt time.Time
+	)
+
+	recent := func() []duplicates.Candidate {
+		since := time.Now().Add(-opts.Duplicates.WindowDuration())
+		candidates := historyCandidates(opts.Profile, since)
+
+		if opts.Duplicates.Check != config.DuplicateCheckAccount || accessToken == "" {
+			return candidates
+		}
+
+		if time.Since(fetchedAt) > accountPostsTTL {
+			// A failed fetch is not retried before the TTL either, so an
+			// outage does not warn on every preview.
+			fetchedAt = time.Now()
+
+			posts, _, err := api.GetUserPosts(ctx, userID, since, accessToken)
+			if err != nil {
+				fmt.Println(Warn("[WARN] "), "could not check your recent posts for duplicates:", err)
+				return append(candidates, fetched...)
+			}
+
+			fetched = fetched[:0]
+
+			for _, post := range posts {
+				createdAt, _ := time.Parse(time.RFC3339, post.CreatedAt)
+				fetched = append(fetched, duplicates.Candidate{ID: post.ID, Text: duplicates.TweetText(post), Time: createdAt})
+			}
+		}
+
+		return append(candidates, fetched...)
+	}
+
+	preview := &previewContext{
+		recent:  recent,
+		resolve: newMentionResolver(ctx, accessToken),
+		edit: func(text string) (string, error) {
+			return editor.EditContent(ctx, text)
+		},
+	}
+
+	if opts.CrossPost != nil {
+		preview.crossPost = func(draft *postDraft) (bool, error) {
+			return crossPostDraft(ctx, draft, opts)
+		}
+	}
+
+	return preview
+}
+
+// historyCandidates returns the posts of profile in the local history that
+// were sent after since and are still up.
+func historyCandidates(profile string, since time.Time) []duplicates.Candidate {
+	records, err := history.Load()
+	if err != nil {
+		fmt.Println(Warn("[WARN] "), err)
+		return nil
+	}
+
+	deleted := map[string]bool{}
+
+	for _, rec := range records {
+		if rec.Action == models.HistoryActionDelete {
+			deleted[rec.ID] = true
+		}
+	}
+
+	var candidates []duplicates.Candidate
+
+	for _, rec := range records {
+		if rec.Action == models.HistoryActionDelete || deleted[rec.ID] || rec.Profile != profile || rec.Time.Before(since) {
+			continue
+		}
+
+		candidates = append(candidates, duplicates.Candidate{ID: rec.ID, Text: rec.Text, Time: rec.Time})
+	}
+
+	return candidates
+}
+
+// warnDuplicate reports whether the draft repeats a recent post, which X
+// rejects only after sending, and shows the post it resembles.
+func warnDuplicate(draft *postDraft, opts Options) bool {
+	if opts.preview == nil || opts.Duplicates.Check == "" || opts.Duplicates.Check == config.DuplicateCheckOff {
+		return false
+	}
+
+	match, found := duplicates.Find(draft.text, opts.preview.recent(), opts.Duplicates.Threshold)
+	if !found {
+		return false
+	}
+
+	resemblance := fmt.Sprintf("nearly identical (%.0f%% similar) to", match.Score*100)
+	if match.Score == 1 {
+		resemblance = "identical to"
+	}
+
+	fmt.Println(Warn("[WARN] "), fmt.Sprintf("this post is %s your post from %s, X may reject it:", resemblance, humanize.Time(match.Time)))
+	fmt.Println(wrapText(match.Text, 60))
+	fmt.Println("------------------------------------------------------------")
+
+	return true
+}
+
+// editFromPreview reopens the draft in the editor from the preview, until it
+// fits or is emptied. It reports false when the draft was emptied.
+func editFromPreview(draft *postDraft, maxPostLength int, opts Options) (bool, error) {
+	for {
+		content, err := opts.preview.edit(draft.text)
+		if err != nil {
+			return false, err
+		}
+
+		draft.text = strings.TrimSpace(content)
+		draft.allowSecrets = false
+
+		if draft.stored != nil {
+			if err := drafts.Record(draft.stored, content); err != nil {
+				fmt.Println(Warn("[WARN] "), "could not autosave draft:", err)
+			}
+		}
+
+		if draft.isEmpty() {
+			return false, nil
+		}
+
+		if err := draft.checkLength(maxPostLength); err != nil {
+			fmt.Println(Failed("[ERROR] "), err, "- reopening the editor")
+			continue
+		}
+
+		return true, nil
+	}
+}
@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"x-dev/internal/history"
+	"x-dev/internal/models"
+
+	"github.com/dustin/go-humanize"
+	"github.com/manifoldco/promptui"
+)
+
+const maxThreadChoices = 20
+
+// recordHistory keeps a successful send or delete in the local history. A
+// failure only warns, since the post itself went through.
+func recordHistory(opts Options, rec models.HistoryRecord) {
+	rec.Profile = opts.Profile
+	rec.Username = opts.Username
+
+	if err := history.Record(rec); err != nil {
+		fmt.Println(Warn("[WARN] "), err)
+	}
+}
+
+func (d *postDraft) historyRecord(postID string, rateLimit *models.RateLimitInfo) models.HistoryRecord {
+	return models.HistoryRecord{
+		ID:            postID,
+		Action:        history.ActionFor(d.replyToID, d.quoteID),
+		Text:          d.text,
+		ReplyTo:       d.replyToID,
+		QuoteID:       d.quoteID,
+		ReplySettings: d.replySettings,
+		CommunityID:   d.communityID(),
+		RateLimit:     rateLimit,
+	}
+}
+
+// historyThreads returns the threads of the profile from the history,
+// warning when the history cannot be read.
+func historyThreads(profile string) []history.Thread {
+	records, err := history.Load()
+	if err != nil {
+		fmt.Println(Warn("[WARN] "), err)
+		return nil
+	}
+
+	return history.Threads(records, profile)
+}
+
+// chooseThread picks the post to continue: the latest post of this session,
+// or the head of any thread in the history.
+func chooseThread(latestPost *models.LatestPost, opts Options) (*models.LatestPost, error) {
+	var (
+		choices []models.LatestPost
+		items   []string
+	)
+
+	if latestPost.Text != "" {
+		choices = append(choices, *latestPost)
+		items = append(items, "Latest post: "+truncate(strings.ReplaceAll(latestPost.Text, "\n", " "), 50))
+	}
+
+	for _, t := range historyThreads(opts.Profile) {
+		if len(choices) == maxThreadChoices {
+			break
+		}
+
+		if t.Head.ID == latestPost.PostID {
+			continue
+		}
+
+		choices = append(choices, models.LatestPost{PostID: t.Head.ID, Text: t.Head.Text})
+		items = append(items, fmt.Sprintf("%s, %d post(s): %s", humanize.Time(t.Head.Time), t.Posts,
+			truncate(strings.ReplaceAll(t.Head.Text, "\n", " "), 40)))
+	}
+
+	switch {
+	case len(choices) == 0:
+		return nil, errors.New("no thread to add to yet")
+	case len(choices) == 1 && latestPost.Text != "":
+		return &choices[0], nil
+	}
+
+	threadPrompt := promptui.Select{
+		Label: "Choose a thread to add to",
+		Items: items,
+	}
+
+	index, _, err := threadPrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("thread selection failed: %w", err)
+	}
+
+	return &choices[index], nil
+}
@@ -0,0 +1,31 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"x-dev/internal/links"
+)
+
+// printLinkChanges lists the links the profile's rules rewrote that are
+// still in text, so an edit that removed one does not leave it listed.
+func printLinkChanges(changes []links.Change, text string) {
+	printed := false
+
+	for _, change := range changes {
+		if !strings.Contains(text, change.To) {
+			continue
+		}
+
+		if !printed {
+			fmt.Println("Links rewritten (each counts as 23 characters):")
+			printed = true
+		}
+
+		fmt.Printf("  %s\n  → %s\n", change.From, change.To)
+	}
+
+	if printed {
+		fmt.Println("------------------------------------------------------------")
+	}
+}
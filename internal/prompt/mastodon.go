@@ -0,0 +1,194 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+
+	"x-dev/internal/mastodon"
+	"x-dev/internal/models"
+
+	"github.com/manifoldco/promptui"
+)
+
+var mastodonVisibilityLabels = []struct {
+	value string
+	label string
+}{
+	{models.MastodonVisibilityPublic, "Public"},
+	{models.MastodonVisibilityUnlisted, "Unlisted, not on public timelines"},
+	{models.MastodonVisibilityPrivate, "Followers only"},
+	{models.MastodonVisibilityDirect, "Mentioned people only"},
+}
+
+// canPostToMastodon reports whether the draft can be copied to Mastodon.
+// Replies and quotes point at posts on X, which Mastodon has no copy of.
+func (d *postDraft) canPostToMastodon() bool {
+	return d.replyToID == "" && d.replyToQueued == "" && d.quoteID == ""
+}
+
+// mastodonDefaults returns the options a new draft starts with, nil when
+// the profile does not send to Mastodon by default.
+func mastodonDefaults(opts Options) *mastodon.Options {
+	if opts.Mastodon == nil || !opts.Mastodon.Enabled {
+		return nil
+	}
+
+	defaults := opts.Mastodon.Defaults
+
+	return &defaults
+}
+
+// printMastodon shows where the Mastodon copy goes and reports whether the
+// instance would take it. A copy it would reject holds the post back, so
+// the post does not go out on X alone.
+func printMastodon(draft *postDraft, target *mastodon.Target) bool {
+	if draft.mastodon == nil {
+		fmt.Println("Mastodon: off")
+		fmt.Println("------------------------------------------------------------")
+
+		return true
+	}
+
+	length := target.Limits.Length(draft.text)
+
+	fmt.Printf("Mastodon: %s, %s (%d/%d characters)\n", target.Label(), mastodonVisibilityLabel(draft.mastodon.Visibility), length, target.Limits.MaxCharacters)
+
+	if draft.mastodon.ContentWarning != "" {
+		fmt.Println("Content warning:", draft.mastodon.ContentWarning)
+	}
+
+	fits := true
+
+	if length > target.Limits.MaxCharacters {
+		fmt.Println(Failed("[ERROR] "), fmt.Sprintf("too long for %s, shorten the post or turn the Mastodon copy off", target.Label()))
+		fits = false
+	}
+
+	if len(draft.attachments) > target.Limits.MaxMedia {
+		fmt.Println(Failed("[ERROR] "), fmt.Sprintf("%s takes at most %d attachments, remove some or turn the Mastodon copy off", target.Label(), target.Limits.MaxMedia))
+		fits = false
+	}
+
+	fmt.Println("------------------------------------------------------------")
+
+	return fits
+}
+
+// promptMastodon edits the Mastodon options of the draft until Done is
+// chosen. nil turns the Mastodon copy off.
+func promptMastodon(current *mastodon.Options, target *mastodon.Target) (*mastodon.Options, error) {
+	for {
+		var items []string
+
+		if current == nil {
+			items = []string{"Also post to " + target.Label(), "Done"}
+		} else {
+			items = []string{
+				"Don't post to Mastodon",
+				"Visibility: " + mastodonVisibilityLabel(current.Visibility),
+				"Content warning: " + contentWarningLabel(current.ContentWarning),
+				"Done",
+			}
+		}
+
+		optionsPrompt := promptui.Select{
+			Label: "Mastodon",
+			Items: items,
+		}
+
+		index, _, err := optionsPrompt.Run()
+		if err != nil {
+			return current, fmt.Errorf("mastodon selection failed: %w", err)
+		}
+
+		switch {
+		case index == len(items)-1:
+			return current, nil
+
+		case current == nil:
+			defaults := target.Defaults
+			current = &defaults
+
+		case index == 0:
+			current = nil
+
+		case index == 1:
+			if current.Visibility, err = promptMastodonVisibility(current.Visibility); err != nil {
+				return current, err
+			}
+
+		case index == 2:
+			warningPrompt := promptui.Prompt{
+				Label:   "Content warning (empty for none)",
+				Default: current.ContentWarning,
+			}
+
+			warning, err := warningPrompt.Run()
+			if err != nil {
+				return current, fmt.Errorf("content warning prompt failed: %w", err)
+			}
+
+			current.ContentWarning = warning
+		}
+	}
+}
+
+func promptMastodonVisibility(current string) (string, error) {
+	labels := make([]string, 0, len(mastodonVisibilityLabels))
+	cursor := 0
+
+	for i, option := range mastodonVisibilityLabels {
+		labels = append(labels, option.label)
+
+		if option.value == current {
+			cursor = i
+		}
+	}
+
+	visibilityPrompt := promptui.Select{
+		Label:     "Who can see the Mastodon post",
+		Items:     labels,
+		CursorPos: cursor,
+	}
+
+	index, _, err := visibilityPrompt.Run()
+	if err != nil {
+		return current, fmt.Errorf("visibility selection failed: %w", err)
+	}
+
+	return mastodonVisibilityLabels[index].value, nil
+}
+
+func mastodonVisibilityLabel(value string) string {
+	for _, option := range mastodonVisibilityLabels {
+		if option.value == value {
+			return option.label
+		}
+	}
+
+	return value
+}
+
+func contentWarningLabel(warning string) string {
+	if warning == "" {
+		return "none"
+	}
+
+	return warning
+}
+
+// sendToMastodon posts the Mastodon copy of a draft that went out on X as
+// postID. The X post ID doubles as the idempotency key.
+func sendToMastodon(ctx context.Context, draft *postDraft, opts Options, postID string) {
+	if draft.mastodon == nil || opts.Mastodon == nil || !draft.canPostToMastodon() {
+		return
+	}
+
+	status, err := mastodon.Post(ctx, opts.Mastodon.Login, draft.text, draft.attachments, draft.poll, *draft.mastodon, "x-yapper-"+postID)
+	if err != nil {
+		fmt.Println(Failed("[ERROR] "), "the post is on X but not on Mastodon:", err)
+		return
+	}
+
+	fmt.Println("\U00002705 Posted to Mastodon:", status.URL)
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"x-dev/internal/mentions"
+	"x-dev/internal/models"
+)
+
+// mentionRetryAfter keeps a failed lookup from being repeated, and warned
+// about, on every redraw of the preview.
+const mentionRetryAfter = time.Minute
+
+// newMentionResolver returns a lookup that asks X only about usernames it
+// has not seen yet in this session, all of them in one request.
+func newMentionResolver(ctx context.Context, accessToken string) func(usernames []string) map[string]mentions.Result {
+	var failedAt time.Time
+
+	resolved := map[string]mentions.Result{}
+
+	return func(usernames []string) map[string]mentions.Result {
+		var missing []string
+
+		for _, username := range usernames {
+			if _, ok := resolved[strings.ToLower(username)]; !ok {
+				missing = append(missing, username)
+			}
+		}
+
+		if len(missing) > 0 && accessToken != "" && time.Since(failedAt) > mentionRetryAfter {
+			results, err := mentions.Resolve(ctx, missing, accessToken)
+			if err != nil {
+				failedAt = time.Now()
+				fmt.Println(Warn("[WARN] "), "could not check the mentions:", err)
+			}
+
+			for key, result := range results {
+				resolved[key] = result
+			}
+		}
+
+		return resolved
+	}
+}
+
+// rememberUsers keeps accounts seen in the timeline and in looked up posts
+// for "x-yapper complete-mention".
+func rememberUsers(users []models.User) {
+	if err := mentions.Remember(users); err != nil {
+		fmt.Println(Warn("[WARN] "), "could not remember handles:", err)
+	}
+}
+
+// warnMentions flags mentions of accounts that do not exist or are
+// suspended, which would tag a stranger or nobody. It reports whether any
+// were flagged.
+func warnMentions(draft *postDraft, opts Options) bool {
+	if opts.preview == nil || opts.preview.resolve == nil {
+		return false
+	}
+
+	usernames := mentions.Usernames(draft.text)
+	if len(usernames) == 0 {
+		return false
+	}
+
+	resolved := opts.preview.resolve(usernames)
+	flagged := false
+
+	for _, username := range usernames {
+		result, ok := resolved[strings.ToLower(username)]
+		if !ok || result.Status == mentions.StatusFound {
+			continue
+		}
+
+		fmt.Println(Warn("[WARN] "), mentions.Describe(result))
+
+		flagged = true
+	}
+
+	if flagged {
+		fmt.Println("------------------------------------------------------------")
+	}
+
+	return flagged
+}
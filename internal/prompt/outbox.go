@@ -0,0 +1,87 @@
+package prompt
+
+import (
+	"fmt"
+
+	"x-dev/internal/models"
+	"x-dev/internal/outbox"
+)
+
+const outboxHint = "It is sent by \"x-yapper outbox flush\" or the next time x-yapper starts."
+
+// queueDraft moves a draft that could not reach X into the outbox and makes
+// it the latest post, so further thread entries queue behind it.
+func queueDraft(draft *postDraft, opts Options, latestPost *models.LatestPost, reason error) {
+	item := &models.OutboxItem{
+		Profile:      opts.Profile,
+		ReplyToItem:  draft.replyToQueued,
+		OutgoingPost: draft.outgoing(),
+	}
+
+	if err := outbox.Add(item); err != nil {
+		fmt.Println(Failed("[ERROR] "), "could not save the post to the outbox:", err)
+		return
+	}
+
+	if draft.stored != nil && draft.stored.ID != "" {
+		forgetDraft(draft.stored.ID)
+	}
+
+	latestPost.PostID = ""
+	latestPost.OutboxID = item.ID
+	latestPost.Text = draft.text
+
+	if reason != nil {
+		fmt.Println(Warn("[WARN] "), "X is unreachable:", reason)
+	}
+
+	fmt.Printf("\U0001F4EE Post saved to the outbox as item %s. %s\n", item.ID, outboxHint)
+}
+
+// queueThread moves the unsent rest of a thread into the outbox, chaining
+// each post to the one before it.
+func queueThread(t *models.Thread, from int, opts Options, latestPost *models.LatestPost) error {
+	var previous *models.OutboxItem
+
+	for i := from; i < len(t.Posts); i++ {
+		post := t.Posts[i]
+
+		item := &models.OutboxItem{
+			Profile: opts.Profile,
+			OutgoingPost: models.OutgoingPost{
+				Text:         post.Text,
+				AllowSecrets: t.AllowSecrets,
+			},
+		}
+
+		for _, attachment := range post.Attachments {
+			item.MediaPaths = append(item.MediaPaths, attachment.Path)
+			item.AltTexts = append(item.AltTexts, attachment.AltText)
+		}
+
+		switch {
+		case previous != nil:
+			item.ReplyToItem = previous.ID
+		case i > 0:
+			item.ReplyTo = t.Posts[i-1].PostID
+		default:
+			item.ReplySettings = t.ReplySettings
+		}
+
+		if err := outbox.Add(item); err != nil {
+			return err
+		}
+
+		previous = item
+	}
+
+	if previous != nil {
+		latestPost.PostID = ""
+		latestPost.OutboxID = previous.ID
+		latestPost.Text = t.Posts[len(t.Posts)-1].Text
+	}
+
+	fmt.Printf("\U0001F4EE %d post(s) of the thread saved to the outbox. %s\n", len(t.Posts)-from, outboxHint)
+
+	return nil
+}
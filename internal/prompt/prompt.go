@@ -10,7 +10,18 @@ import (
 
 	"x-dev/internal/api"
 	"x-dev/internal/config"
+	"x-dev/internal/crosspost"
+	"x-dev/internal/drafts"
+	"x-dev/internal/links"
+	"x-dev/internal/lint"
+	"x-dev/internal/mastodon"
+	"x-dev/internal/media"
 	"x-dev/internal/models"
+	"x-dev/internal/poll"
+	"x-dev/internal/secrets"
+	"x-dev/internal/spelling"
+	"x-dev/internal/thread"
+	"x-dev/internal/twittertext"
 
 	"github.com/dustin/go-humanize"
 	"github.com/eiannone/keyboard"
@@ -25,12 +36,60 @@ var (
 	Failed  = promptui.Styler(promptui.FGRed)
 )
 
-func RunPrompts(ctx context.Context, tokenResp *models.TokenResponse, maxPostLength int, userResponse models.UserResponse) error {
+type Options struct {
+	MediaPaths      []string
+	Upload          media.UploadOptions
+	AltTextPolicy   string
+	ThreadSeparator string
+	UndoSeconds     int
+	ReplySettings   string
+	Profile         string
+	Username        string
+	Duplicates      config.DuplicateSettings
+	Lint            *lint.Linter
+	// KnownSecrets are x-yapper's own credentials, which the secret
+	// scanner looks for literally.
+	KnownSecrets []string
+	Spelling     *spelling.Checker
+	Links        *links.Rewriter
+	// Communities are the X Communities the profile can post to.
+	Communities []models.Community
+	// CrossPost sends a draft from several profiles. It is nil when there
+	// are no other profiles to post from.
+	CrossPost *crosspost.Poster
+	// Mastodon is the profile's Mastodon account, which new posts can be
+	// copied to. It is nil when the profile has none.
+	Mastodon *mastodon.Target
+
+	preview *previewContext
+}
+
+var replySettingsLabels = []struct {
+	value string
+	label string
+}{
+	{"", "Everyone"},
+	{models.ReplySettingsFollowing, "Accounts you follow"},
+	{models.ReplySettingsMentioned, "Only accounts you mention"},
+}
+
+var resumeSelections = map[string]string{
+	models.DraftKindPost:   "Start new post",
+	models.DraftKindReply:  "Reply to post",
+	models.DraftKindQuote:  "Quote post",
+	models.DraftKindPoll:   "Start new poll",
+	models.DraftKindThread: "Start new thread",
+}
+
+func RunPrompts(ctx context.Context, tokenResp *models.TokenResponse, maxPostLength int, userResponse models.UserResponse, opts Options) error {
 	econfig := config.NewEditorConfig()
 	editor, err := econfig.ChooseEditor()
 	if err != nil {
 		return fmt.Errorf("editor initialization failed: %w", err)
 	}
+
+	opts.preview = newPreviewContext(ctx, editor, opts, tokenResp.AccessToken, userResponse.Data.ID)
+
 	showHeader()
 	fmt.Printf("Authenticated as %v (@%v)", userResponse.Data.Name, userResponse.Data.Username)
 	fmt.Println()
@@ -41,31 +100,91 @@ func RunPrompts(ctx context.Context, tokenResp *models.TokenResponse, maxPostLen
 		Text:   "",
 	}
 
+	pendingMedia := opts.MediaPaths
+	mediaLimits := media.LimitsFor(userResponse.Data.Verified)
+
+	// resumeDraft is set by the Drafts menu; the next loop iteration then
+	// skips the main menu and reopens the draft in the matching composer.
+	var resumeDraft *models.Draft
+
+	storedDraft := func(kind string, targetID string) *models.Draft {
+		if resumeDraft != nil {
+			stored := resumeDraft
+			resumeDraft = nil
+
+			return stored
+		}
+
+		return drafts.New(kind, targetID)
+	}
+
 	for {
-		userSelection, err := runMainPrompt(latestPost)
-		if err != nil {
-			return fmt.Errorf("main prompt failed: %w", err)
+		var userSelection string
+
+		if resumeDraft != nil {
+			userSelection = resumeSelections[resumeDraft.Kind]
+		} else {
+			pending, err := thread.LoadPending()
+			if err != nil {
+				fmt.Println(Warn("[WARN] "), err)
+			}
+
+			savedDrafts, err := drafts.List()
+			if err != nil {
+				fmt.Println(Warn("[WARN] "), err)
+			}
+
+			userSelection, err = runMainPrompt(latestPost, len(pending), len(savedDrafts), len(historyThreads(opts.Profile)))
+			if err != nil {
+				return fmt.Errorf("main prompt failed: %w", err)
+			}
 		}
 
 		switch userSelection {
 		case "Start new post":
-			content, err := editor.OpenEditor(ctx)
+			draft, err := composePost(ctx, editor, storedDraft(models.DraftKindPost, ""), pendingMedia, mediaLimits)
 			if err != nil {
 				fmt.Println(Failed("[ERROR] "), err)
 				continue
 			}
 
-			if strings.TrimSpace(content) == "" {
+			pendingMedia = nil
+			draft.replySettings = opts.ReplySettings
+			draft.mastodon = mastodonDefaults(opts)
+
+			if draft.isEmpty() {
 				fmt.Println(Warn("[WARN] "), "No content entered. Returning to main prompt.")
 				continue
 			}
 
-			if len(content) > maxPostLength {
-				fmt.Println(Failed("[ERROR] "), "post exceeds maximum length of", maxPostLength, "characters.")
+			if draft.checkLength(maxPostLength) != nil {
+				splitThread, err := offerThreadSplit(ctx, editor, draft, opts.ThreadSeparator, maxPostLength, mediaLimits)
+				if err != nil {
+					fmt.Println(Failed("[ERROR] "), err)
+					continue
+				}
+
+				if splitThread == nil {
+					fmt.Println("\U0000274C Post discarded.")
+					continue
+				}
+
+				previewResponse, err := showThreadPreviewPrompt(splitThread, maxPostLength, opts)
+				if err != nil {
+					fmt.Println(Failed("[ERROR] "), err)
+					continue
+				}
+
+				if previewResponse == 0 {
+					sendThread(ctx, splitThread, tokenResp.AccessToken, opts, latestPost)
+				} else {
+					fmt.Println("\U0000274C Thread discarded.")
+				}
+
 				continue
 			}
 
-			previewResponse, err := showPreviewPrompt(content)
+			previewResponse, err := showPreviewPrompt(draft, maxPostLength, opts)
 			if err != nil {
 				fmt.Println(Failed("[ERROR] "), err)
 				continue
@@ -73,83 +192,250 @@ func RunPrompts(ctx context.Context, tokenResp *models.TokenResponse, maxPostLen
 
 			switch previewResponse {
 			case 0:
-				var postResponse *models.PostResponse
-				var rateLimit *models.RateLimitInfo
-				postResponse, rateLimit, err = api.SendPost(ctx, content, tokenResp.AccessToken)
-				if err != nil {
-					fmt.Println(Failed("[ERROR] "), err)
-				} else {
-					postID := postResponse.Data.ID
-					fmt.Println("\U00002705 Post Successful! Post ID: ", postID)
-					latestPost.PostID = postID
-					latestPost.Text = content
-				}
-				rateLimitStatus := rateLimitStatus(rateLimit)
+				sendWithUndo(ctx, editor, draft, maxPostLength, tokenResp.AccessToken, opts, latestPost)
 
-				if rateLimitStatus != "" {
-					fmt.Println(rateLimitStatus)
-				}
+			case 2:
+				saveDraft(draft)
 
-			case 1:
-				fmt.Println("\U0000274C Post discarded.")
+			case 3:
+				scheduleDraft(draft, opts)
+
+			case 4:
 
 			default:
 				fmt.Println("\U0000274C Post discarded.")
 
 			}
+
+		case "Start new poll":
+			draft, err := composePoll(ctx, editor, storedDraft(models.DraftKindPoll, ""), maxPostLength)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			draft.replySettings = opts.ReplySettings
+			draft.mastodon = mastodonDefaults(opts)
+
+			previewResponse, err := showPreviewPrompt(draft, maxPostLength, opts)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			switch previewResponse {
+			case 0:
+				sendWithUndo(ctx, editor, draft, maxPostLength, tokenResp.AccessToken, opts, latestPost)
+
+			case 2:
+				saveDraft(draft)
+
+			case 3:
+				scheduleDraft(draft, opts)
+
+			case 4:
+
+			default:
+				fmt.Println("\U0000274C Poll discarded.")
+			}
+
+		case "Start new thread":
+			newThread, err := composeThread(ctx, editor, storedDraft(models.DraftKindThread, ""), opts.ThreadSeparator, maxPostLength, mediaLimits)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			newThread.ReplySettings = opts.ReplySettings
+
+			previewResponse, err := showThreadPreviewPrompt(newThread, maxPostLength, opts)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			if previewResponse == 0 {
+				sendThread(ctx, newThread, tokenResp.AccessToken, opts, latestPost)
+			} else {
+				fmt.Println("\U0000274C Thread discarded.")
+			}
+
+		case "Resume unfinished thread":
+			pending, err := thread.LoadPending()
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			if len(pending) == 0 {
+				fmt.Println(Info("[INFO] "), "no unfinished threads.")
+				continue
+			}
+
+			savedThread, resume, err := promptPendingThread(pending)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			if !resume {
+				if err := thread.RemovePending(savedThread.ID); err != nil {
+					fmt.Println(Failed("[ERROR] "), err)
+				} else {
+					fmt.Println("\U0000274C Saved thread deleted.")
+				}
+
+				continue
+			}
+
+			previewResponse, err := showThreadPreviewPrompt(savedThread, maxPostLength, opts)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			if previewResponse == 0 {
+				sendThread(ctx, savedThread, tokenResp.AccessToken, opts, latestPost)
+			}
+
 		case "Add post to latest thread":
-			content, err := editor.OpenEditor(ctx)
+			head, err := chooseThread(latestPost, opts)
 			if err != nil {
 				fmt.Println(Failed("[ERROR] "), err)
+				continue
 			}
 
-			if strings.TrimSpace(content) == "" {
+			draft, err := composePost(ctx, editor, drafts.New(models.DraftKindReply, head.PostID), nil, mediaLimits)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			if draft.isEmpty() {
 				fmt.Println(Warn("[WARN] "), "No content entered. Returning to main prompt.")
+				continue
 			}
 
-			if len(content) > maxPostLength {
-				fmt.Println(Failed("[ERROR] "), "post exceeds maximum length of", maxPostLength, "characters.")
+			if err := draft.checkLength(maxPostLength); err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			draft.replyToID = head.PostID
+
+			if head.PostID == "" {
+				draft.replyToQueued = head.OutboxID
 			}
 
-			previewResponse, err := showPreviewPrompt(content)
+			previewResponse, err := showPreviewPrompt(draft, maxPostLength, opts)
 			if err != nil {
 				fmt.Println(Failed("[ERROR] "), err)
+				continue
 			}
 
 			switch previewResponse {
 			case 0:
-				replyToPostID := &models.Reply{ReplyID: latestPost.PostID}
-				threadPost := &models.ReplyPost{
-					Text:  content,
-					Reply: replyToPostID,
-				}
+				sendReplyPost(ctx, draft, tokenResp.AccessToken, opts, latestPost, "Posting to Thread Successful!")
+
+			case 1:
+				fmt.Println("\U0000274C Post discarded.")
+
+			case 2:
+				saveDraft(draft)
 
-				var postResponse *models.PostResponse
-				var rateLimit *models.RateLimitInfo
-				postResponse, rateLimit, err = api.SendReplyPost(ctx, threadPost, tokenResp.AccessToken)
+			case 3:
+				scheduleDraft(draft, opts)
+
+			default:
+				fmt.Println(Warn("[WARN]"), "unable to determine selection, returning to main menu.")
+
+			}
+
+		case "Reply to post", "Quote post":
+			kind := models.DraftKindQuote
+			if userSelection == "Reply to post" {
+				kind = models.DraftKindReply
+			}
+
+			var target *targetPost
+
+			if resumeDraft != nil {
+				target, err = fetchTargetPost(ctx, resumeDraft.TargetID, tokenResp.AccessToken)
+			} else {
+				target, err = promptTargetPost(ctx, tokenResp.AccessToken)
+			}
+
+			if err != nil {
+				resumeDraft = nil
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			draft, err := composePost(ctx, editor, storedDraft(kind, target.tweet.ID), nil, mediaLimits)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			if draft.isEmpty() {
+				fmt.Println(Warn("[WARN] "), "No content entered. Returning to main prompt.")
+				continue
+			}
+
+			if err := draft.checkLength(maxPostLength); err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			draft.target = target
+
+			successMessage := "Quote Successful!"
+
+			if userSelection == "Reply to post" {
+				draft.replyToID = target.tweet.ID
+				successMessage = "Reply Successful!"
+
+				draft.excludeReplyUserIDs, err = promptExcludedMentions(target)
 				if err != nil {
 					fmt.Println(Failed("[ERROR] "), err)
-				} else {
-					postID := postResponse.Data.ID
-					fmt.Println("\U00002705 Posting to Thread Successful! Post ID: ", postID)
-					latestPost.PostID = postID
-					latestPost.Text = content
+					continue
 				}
+			} else {
+				draft.quoteID = target.tweet.ID
+				draft.replySettings = opts.ReplySettings
+			}
 
-				rateLimitStatus := rateLimitStatus(rateLimit)
+			previewResponse, err := showPreviewPrompt(draft, maxPostLength, opts)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
 
-				if rateLimitStatus != "" {
-					fmt.Println(rateLimitStatus)
-				}
+			switch previewResponse {
+			case 0:
+				sendReplyPost(ctx, draft, tokenResp.AccessToken, opts, latestPost, successMessage)
 
-			case 1:
-				fmt.Println("\U0000274C Post discarded.")
+			case 2:
+				saveDraft(draft)
+
+			case 3:
+				scheduleDraft(draft, opts)
+
+			case 4:
 
 			default:
-				fmt.Println(Warn("[WARN]"), "unable to determine selection, returning to main menu.")
+				fmt.Println("\U0000274C Post discarded.")
+			}
 
+		case "Drafts":
+			resumeDraft, err = promptDrafts()
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
 			}
 
+		case "Delete post":
+			deletePost(ctx, userResponse.Data.ID, tokenResp.AccessToken, opts, latestPost)
+
 		case "Show timeline":
 			var timelineResponse *models.TimelineResponse
 			var rateLimit *models.RateLimitInfo
@@ -157,6 +443,8 @@ func RunPrompts(ctx context.Context, tokenResp *models.TokenResponse, maxPostLen
 			if err != nil {
 				fmt.Println(Failed("[ERROR]"), err)
 			} else {
+				rememberUsers(timelineResponse.Includes.Users)
+
 				err = paginatePosts(timelineResponse)
 				if err != nil {
 					fmt.Println(Failed("[ERROR] "), err)
@@ -179,7 +467,7 @@ func RunPrompts(ctx context.Context, tokenResp *models.TokenResponse, maxPostLen
 	} // end, return to main menu
 }
 
-func runMainPrompt(latestPost *models.LatestPost) (string, error) {
+func runMainPrompt(latestPost *models.LatestPost, pendingThreads int, savedDrafts int, threads int) (string, error) {
 	type PromptOption struct {
 		Name    string
 		Details string
@@ -191,6 +479,75 @@ func runMainPrompt(latestPost *models.LatestPost) (string, error) {
 			Details: "  Create new post",
 		},
 		{
+			Name:    "Start new thread",
+			Details: "  Write several posts in one editor session, separated by a separator line",
+		},
+	}
+
+	if pendingThreads > 0 {
+		mainPromptOptions = append(mainPromptOptions, PromptOption{
+			Name:    "Resume unfinished thread",
+			Details: fmt.Sprintf("  %d thread(s) stopped partway through", pendingThreads),
+		})
+	}
+
+	if savedDrafts > 0 {
+		mainPromptOptions = append(mainPromptOptions, PromptOption{
+			Name:    "Drafts",
+			Details: fmt.Sprintf("  %d draft(s) to preview, resume or delete", savedDrafts),
+		})
+	}
+
+	mainPromptOptions = append(mainPromptOptions, PromptOption{
+		Name:    "Start new poll",
+		Details: "  Create a post with 2-4 poll options",
+	})
+
+	if latestPost.Text != "" {
+		wrappedText := wrapText(latestPost.Text, 60)
+
+		postRef := "Post ID: " + latestPost.PostID
+		if latestPost.PostID == "" && latestPost.OutboxID != "" {
+			postRef = "Waiting in the outbox as item " + latestPost.OutboxID
+		}
+
+		details := fmt.Sprintf(
+			"  Reply to the most recently created thread\n"+
+				"  %s\n"+
+				"------------------------------------------------------------\n"+
+				"%s\n"+
+				"------------------------------------------------------------",
+			postRef, wrappedText)
+
+		if threads > 1 {
+			details += "\n  or pick another of your threads from the history"
+		}
+
+		mainPromptOptions = append(mainPromptOptions, PromptOption{
+			Name:    "Add post to latest thread",
+			Details: details,
+		})
+	} else if threads > 0 {
+		mainPromptOptions = append(mainPromptOptions, PromptOption{
+			Name:    "Add post to latest thread",
+			Details: fmt.Sprintf("  Reply to one of your %d thread(s) from the history", threads),
+		})
+	}
+
+	mainPromptOptions = append(mainPromptOptions,
+		PromptOption{
+			Name:    "Reply to post",
+			Details: "  Reply to any post by ID or x.com link",
+		},
+		PromptOption{
+			Name:    "Quote post",
+			Details: "  Quote any post by ID or x.com link",
+		},
+		PromptOption{
+			Name:    "Delete post",
+			Details: "  Delete one of your posts by ID or x.com link",
+		},
+		PromptOption{
 			Name: "Show timeline",
 			Details: fmt.Sprintf(
 				"  View recent posts and interactions\n  "+
@@ -198,27 +555,11 @@ func runMainPrompt(latestPost *models.LatestPost) (string, error) {
 					"%s x-developer free tier has a limit of 100 post pulls a month for the timeline endpoint.",
 				Warn("[WARN]"), Warn("[WARN]")),
 		},
-		{
+		PromptOption{
 			Name:    "Exit",
 			Details: "  Close the application",
 		},
-	}
-
-	if latestPost.Text != "" {
-		wrappedText := wrapText(latestPost.Text, 60)
-		mainPromptOptions = append(mainPromptOptions[:1], append([]PromptOption{
-			{
-				Name: "Add post to latest thread",
-				Details: fmt.Sprintf(
-					"  Reply to the most recently created thread\n"+
-						"  Post ID: %s\n"+
-						"------------------------------------------------------------\n"+
-						"%s\n"+
-						"------------------------------------------------------------",
-					latestPost.PostID, wrappedText),
-			},
-		}, mainPromptOptions[1:]...)...)
-	}
+	)
 
 	prompt := promptui.Select{
 		Label: "Choose an action",
@@ -240,18 +581,288 @@ func runMainPrompt(latestPost *models.LatestPost) (string, error) {
 	return mainPromptOptions[selectedIndex].Name, nil
 }
 
-func showPreviewPrompt(content string) (int, error) {
-	wrappedContent := wrapText(content, 60)
+// showPreviewPrompt returns 0 to send the draft, 1 to discard it, 2 to
+// keep it in the drafts store, 3 to schedule it for later and 4 when it
+// was already sent from several profiles.
+func showPreviewPrompt(draft *postDraft, maxPostLength int, opts Options) (int, error) {
+	altTextPolicy := opts.AltTextPolicy
+
+	var rewritten []links.Change
+
+	for {
+		if text, changes := opts.Links.Rewrite(draft.text); len(changes) > 0 {
+			draft.text = text
+			rewritten = changes
+		}
+
+		var found []secrets.Match
+		if !draft.allowSecrets {
+			found = secrets.Scan(draft.text, opts.KnownSecrets)
+		}
+
+		content := wrapText(draft.text, 60)
+		misspelled := opts.Spelling.Check(content)
+
+		// Wrapping would shift the match offsets, so a post with a possible
+		// secret is shown as typed.
+		if len(found) > 0 {
+			content = HighlightSecrets(draft.text, found)
+		} else {
+			content = underlineMisspellings(content, misspelled)
+		}
+
+		fmt.Printf("\nPost Preview (%d/%d characters):\n", twittertext.WeightedLength(draft.text), maxPostLength)
+		fmt.Println("------------------------------------------------------------")
+		fmt.Println(content)
+		fmt.Println("------------------------------------------------------------")
+
+		printLinkChanges(rewritten, draft.text)
+
+		findings := opts.Lint.Run(lint.Post{
+			Text:        draft.text,
+			Attachments: draft.attachments,
+			MaxLength:   maxPostLength,
+			Reply:       draft.replyToID != "" || draft.replyToQueued != "",
+		})
+
+		blocked := PrintFindings(findings)
+
+		printMisspellings(misspelled)
+
+		if len(found) > 0 {
+			fmt.Println(Failed("[ERROR] "), "possible secret:", secrets.Kinds(found))
+			fmt.Println("------------------------------------------------------------")
+
+			blocked = true
+		}
+
+		if draft.target != nil {
+			fmt.Println(draft.target.summary(draft.quoteID != ""))
+			fmt.Println("------------------------------------------------------------")
+		}
+
+		if draft.poll != nil {
+			fmt.Printf("Poll (%s):\n", poll.FormatDuration(draft.poll.DurationMinutes))
+			for i, option := range draft.poll.Options {
+				fmt.Printf("  %d. %s\n", i+1, option)
+			}
+			fmt.Println("------------------------------------------------------------")
+		}
+
+		var extraActions []string
+
+		if draft.canRestrictReplies() {
+			fmt.Println("Who can reply:", replySettingsLabel(draft.replySettings))
+			fmt.Println("------------------------------------------------------------")
+
+			extraActions = append(extraActions, "Change who can reply")
+		}
+
+		if draft.canPostToCommunity() && (len(opts.Communities) > 0 || draft.community != nil) {
+			fmt.Println("Post to:", draft.communityLabel())
+			fmt.Println("------------------------------------------------------------")
+
+			extraActions = append(extraActions, "Choose community")
+		}
+
+		// Only sending carries the Mastodon copy, so a copy the instance
+		// would reject holds back sending but not scheduling.
+		mastodonRejected := false
+
+		if opts.Mastodon != nil && draft.canPostToMastodon() {
+			mastodonRejected = !printMastodon(draft, opts.Mastodon)
+
+			extraActions = append(extraActions, "Mastodon options")
+		}
+
+		if draft.stored != nil && draft.stored.ID != "" {
+			extraActions = append(extraActions, "Save as draft")
+		}
+
+		missingAltText := printAttachments(draft.attachments)
+
+		duplicate := warnDuplicate(draft, opts)
+		unresolved := warnMentions(draft, opts)
+
+		if (duplicate || unresolved || mastodonRejected || len(findings) > 0 || len(found) > 0 || len(misspelled) > 0) && opts.preview != nil && opts.preview.edit != nil {
+			extraActions = append([]string{"Edit post"}, extraActions...)
+		}
+
+		if len(found) > 0 {
+			extraActions = append(extraActions, "Send anyway")
+		}
+
+		if !blocked && draft.canCrossPost() && opts.preview != nil && opts.preview.crossPost != nil && (missingAltText == 0 || altTextPolicy != models.AltTextPolicyRequire) {
+			extraActions = append(extraActions, "Post to profiles")
+		}
+
+		// A reply to a post still in the outbox has no ID to schedule against.
+		if !blocked && draft.replyToQueued == "" && (missingAltText == 0 || altTextPolicy != models.AltTextPolicyRequire) {
+			extraActions = append(extraActions, "Schedule")
+		}
+
+		sendLabel := "Send Post"
+		if blocked || mastodonRejected {
+			sendLabel = ""
+		}
+
+		selection, err := choosePreviewAction(sendLabel, extraActions, missingAltText, altTextPolicy)
+		if err != nil {
+			return 1, err
+		}
+
+		switch selection {
+		case "Send Post":
+			return 0, nil
+
+		case "Save as draft":
+			return 2, nil
+
+		case "Schedule":
+			if draft.mastodon != nil {
+				fmt.Println(Info("[INFO] "), "scheduled posts only go to X, the Mastodon copy is not sent")
+			}
+
+			return 3, nil
+
+		case "Edit post":
+			kept, err := editFromPreview(draft, maxPostLength, opts)
+			if err != nil || !kept {
+				return 1, err
+			}
+
+		case "Send anyway":
+			confirmed, err := confirmSecretOverride(secrets.Kinds(found))
+			if err != nil {
+				return 1, err
+			}
+
+			if confirmed {
+				if err := secrets.LogOverride(opts.Profile, "prompt", draft.text, found); err != nil {
+					return 1, err
+				}
+
+				draft.allowSecrets = true
+			}
+
+		case "Change who can reply":
+			if draft.replySettings, err = promptReplySettings(draft.replySettings); err != nil {
+				return 1, err
+			}
+
+			draft.replySettingsChosen = true
+
+		case "Post to profiles":
+			sent, err := opts.preview.crossPost(draft)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+			}
+
+			if sent {
+				if draft.stored != nil {
+					forgetDraft(draft.stored.ID)
+				}
+
+				return 4, nil
+			}
+
+		case "Mastodon options":
+			if draft.mastodon, err = promptMastodon(draft.mastodon, opts.Mastodon); err != nil {
+				return 1, err
+			}
+
+		case "Choose community":
+			if draft.community, err = promptCommunity(draft.community, opts.Communities); err != nil {
+				return 1, err
+			}
+
+		case "Add alt text":
+			if err := promptAltText(draft.attachments); err != nil {
+				return 1, err
+			}
+
+		default:
+			return 1, nil
+		}
+	}
+}
+
+// PrintFindings shows lint findings under the post text and reports
+// whether any of them is an error, which holds the post back.
+func PrintFindings(findings []lint.Finding) bool {
+	if len(findings) == 0 {
+		return false
+	}
+
+	for _, finding := range findings {
+		label := Warn("[WARN] ")
+		if finding.Severity == config.LintSeverityError {
+			label = Failed("[ERROR] ")
+		}
+
+		fmt.Println(label, finding.Rule+":", finding.Message)
+	}
 
-	fmt.Println("\nPost Preview:")
 	fmt.Println("------------------------------------------------------------")
-	fmt.Println(wrappedContent)
+
+	if lint.HasErrors(findings) {
+		fmt.Println(Failed("[ERROR] "), "fix the errors above before sending.")
+		return true
+	}
+
+	return false
+}
+
+func printAttachments(attachments []*models.MediaAttachment) int {
+	missingAltText := 0
+
+	if len(attachments) == 0 {
+		return missingAltText
+	}
+
+	fmt.Println("Attachments:")
+	for i, attachment := range attachments {
+		fmt.Printf("  %d. %s\n", i+1, media.Describe(attachment))
+
+		if attachment.AltText != "" {
+			fmt.Printf("     Alt text: %s\n", attachment.AltText)
+		} else if media.NeedsAltText(attachment) {
+			missingAltText++
+		}
+	}
 	fmt.Println("------------------------------------------------------------")
+
+	return missingAltText
+}
+
+// choosePreviewAction offers sendLabel, the extra actions and Discard. An
+// empty sendLabel leaves sending out, for drafts with lint errors.
+func choosePreviewAction(sendLabel string, extraActions []string, missingAltText int, altTextPolicy string) (string, error) {
+	var items []string
+
+	if sendLabel != "" {
+		items = append(items, sendLabel)
+	}
+
+	if missingAltText > 0 {
+		switch altTextPolicy {
+		case models.AltTextPolicyWarn:
+			fmt.Println(Warn("[WARN] "), missingAltText, "attachment(s) have no alt text.")
+			items = append(items, "Add alt text")
+
+		case models.AltTextPolicyRequire:
+			fmt.Println(Failed("[ERROR] "), missingAltText, "attachment(s) have no alt text, alt text is required before sending.")
+			items = []string{"Add alt text"}
+		}
+	}
+
+	items = append(append(items, extraActions...), "Discard")
+
 	fmt.Println("")
 
 	prompt := promptui.Select{
 		Label: "Choose an action",
-		Items: []string{"Send Post", "Discard"},
+		Items: items,
 		Templates: &promptui.SelectTemplates{
 			Label:    "{{ . }}?",
 			Active:   "-> {{ . | cyan }}",
@@ -260,12 +871,48 @@ func showPreviewPrompt(content string) (int, error) {
 		},
 	}
 
-	idx, _, err := prompt.Run()
+	_, selection, err := prompt.Run()
 	if err != nil {
-		return 1, fmt.Errorf("preview selection failed: %w", err)
+		return "", fmt.Errorf("preview selection failed: %w", err)
+	}
+
+	return selection, nil
+}
+
+func replySettingsLabel(value string) string {
+	for _, option := range replySettingsLabels {
+		if option.value == value {
+			return option.label
+		}
 	}
 
-	return idx, nil
+	return value
+}
+
+func promptReplySettings(current string) (string, error) {
+	labels := make([]string, 0, len(replySettingsLabels))
+	cursor := 0
+
+	for i, option := range replySettingsLabels {
+		labels = append(labels, option.label)
+
+		if option.value == current {
+			cursor = i
+		}
+	}
+
+	replyPrompt := promptui.Select{
+		Label:     "Who can reply",
+		Items:     labels,
+		CursorPos: cursor,
+	}
+
+	index, _, err := replyPrompt.Run()
+	if err != nil {
+		return current, fmt.Errorf("reply settings selection failed: %w", err)
+	}
+
+	return replySettingsLabels[index].value, nil
 }
 
 func paginatePosts(timelineResponse *models.TimelineResponse) error {
@@ -544,6 +1191,10 @@ func paginateTweetContents(postContents []string, availableHeight int) []string
 }
 
 func rateLimitStatus(rateLimit *models.RateLimitInfo) string {
+	if rateLimit == nil {
+		return ""
+	}
+
 	resetTime := rateLimit.ResetTime.Format("Jan 2 at 3:04 PM")
 
 	if rateLimit.Remaining < 5 && rateLimit.Remaining != 0 {
@@ -0,0 +1,158 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"x-dev/internal/api"
+	"x-dev/internal/models"
+	"x-dev/internal/postref"
+
+	"github.com/manifoldco/promptui"
+)
+
+type targetPost struct {
+	tweet  models.Tweet
+	author *models.User
+}
+
+func (t *targetPost) handle() string {
+	if t.author == nil {
+		return "author " + t.tweet.AuthorID
+	}
+
+	return "@" + t.author.Username
+}
+
+func (t *targetPost) summary(quote bool) string {
+	action := "Replying to"
+	if quote {
+		action = "Quoting"
+	}
+
+	username := ""
+	if t.author != nil {
+		username = t.author.Username
+	}
+
+	return fmt.Sprintf("%s %s: %s\n%s", action, t.handle(),
+		truncate(strings.ReplaceAll(t.tweet.Text, "\n", " "), 50), postref.Permalink(username, t.tweet.ID))
+}
+
+func promptTargetPost(ctx context.Context, accessToken string) (*targetPost, error) {
+	idPrompt := promptui.Prompt{
+		Label: "Post ID or x.com link",
+		Validate: func(input string) error {
+			_, err := postref.ParseID(input)
+			return err
+		},
+	}
+
+	input, err := idPrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("post prompt failed: %w", err)
+	}
+
+	postID, err := postref.ParseID(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return fetchTargetPost(ctx, postID, accessToken)
+}
+
+func fetchTargetPost(ctx context.Context, postID string, accessToken string) (*targetPost, error) {
+	postResponse, rateLimit, err := api.GetPost(ctx, postID, accessToken)
+	if rateLimitStatus := rateLimitStatus(rateLimit); rateLimitStatus != "" {
+		fmt.Println(rateLimitStatus)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	rememberUsers(postResponse.Includes.Users)
+
+	userMap := mapUsersFromTimelineResponse(postResponse.Includes.Users)
+
+	fmt.Println()
+	fmt.Print(formatTweetContent(postResponse.Data, userMap))
+
+	return &targetPost{
+		tweet:  postResponse.Data,
+		author: userMap[postResponse.Data.AuthorID],
+	}, nil
+}
+
+// promptExcludedMentions lets the user drop people mentioned in the target
+// post from the reply, which X otherwise mentions automatically.
+func promptExcludedMentions(target *targetPost) ([]string, error) {
+	if target.tweet.Entities == nil || len(target.tweet.Entities.Mentions) == 0 {
+		return nil, nil
+	}
+
+	mentionIDs := make(map[string]string)
+	handles := make([]string, 0, len(target.tweet.Entities.Mentions))
+
+	for _, mention := range target.tweet.Entities.Mentions {
+		key := strings.ToLower(mention.Username)
+		if _, seen := mentionIDs[key]; seen || mention.ID == "" {
+			continue
+		}
+
+		mentionIDs[key] = mention.ID
+		handles = append(handles, "@"+mention.Username)
+	}
+
+	if len(handles) == 0 {
+		return nil, nil
+	}
+
+	excludePrompt := promptui.Prompt{
+		Label: fmt.Sprintf("Leave out of reply (%s), comma separated, enter for none", strings.Join(handles, " ")),
+		Validate: func(input string) error {
+			for _, handle := range splitHandles(input) {
+				if _, ok := mentionIDs[handle]; !ok {
+					return fmt.Errorf("@%s is not mentioned in the post", handle)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	input, err := excludePrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("mention prompt failed: %w", err)
+	}
+
+	var excluded []string
+	for _, handle := range splitHandles(input) {
+		excluded = append(excluded, mentionIDs[handle])
+	}
+
+	return excluded, nil
+}
+
+func splitHandles(input string) []string {
+	var handles []string
+
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		handle := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(field), "@"))
+		if handle != "" {
+			handles = append(handles, handle)
+		}
+	}
+
+	return handles
+}
+
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit-1]) + "…"
+}
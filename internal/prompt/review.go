@@ -0,0 +1,86 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"x-dev/internal/config"
+	"x-dev/internal/models"
+)
+
+// ReviewPost opens out in the editor and shows the usual preview, so text
+// produced elsewhere, such as a rendered template, is checked like a post
+// written in the prompt. It reports whether out should be sent; edits to
+// the text, reply settings, community and alt text are written back to out.
+func ReviewPost(ctx context.Context, out *models.OutgoingPost, attachments []*models.MediaAttachment, maxPostLength int, opts Options) (bool, error) {
+	editor, err := config.NewEditorConfig().ChooseEditor()
+	if err != nil {
+		return false, fmt.Errorf("editor initialization failed: %w", err)
+	}
+
+	opts.preview = newPreviewContext(ctx, editor, opts, "", "")
+
+	draft := &postDraft{
+		text:                out.Text,
+		attachments:         attachments,
+		poll:                out.Poll,
+		replyToID:           out.ReplyTo,
+		quoteID:             out.QuoteID,
+		excludeReplyUserIDs: out.ExcludeReplyUserIDs,
+		replySettings:       out.ReplySettings,
+		community:           findCommunity(out.CommunityID, opts.Communities),
+	}
+
+	for {
+		content, err := editor.EditContent(ctx, draft.text)
+		if err != nil {
+			return false, err
+		}
+
+		draft.text = strings.TrimSpace(content)
+		draft.allowSecrets = false
+
+		if draft.isEmpty() {
+			fmt.Println("\U0000274C Post discarded.")
+			return false, nil
+		}
+
+		// A post that is too long goes back to the editor rather than being
+		// dropped, since the text came from a template the user did not type.
+		if err := draft.checkLength(maxPostLength); err != nil {
+			fmt.Println(Failed("[ERROR] "), err, "- reopening the editor")
+			continue
+		}
+
+		previewResponse, err := showPreviewPrompt(draft, maxPostLength, opts)
+		if err != nil {
+			return false, err
+		}
+
+		switch previewResponse {
+		case 0:
+			out.Text = draft.text
+			out.ReplySettings = draft.replySettings
+			out.CommunityID = draft.communityID()
+			out.AllowSecrets = draft.allowSecrets
+			out.AltTexts = out.AltTexts[:0]
+
+			for _, attachment := range draft.attachments {
+				out.AltTexts = append(out.AltTexts, attachment.AltText)
+			}
+
+			return true, nil
+
+		case 3:
+			scheduleDraft(draft, opts)
+
+		case 4:
+
+		default:
+			fmt.Println("\U0000274C Post discarded.")
+		}
+
+		return false, nil
+	}
+}
@@ -0,0 +1,78 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/schedule"
+
+	"github.com/manifoldco/promptui"
+)
+
+// scheduleDraft queues the draft for "scheduler run" and reports whether it
+// was scheduled.
+func scheduleDraft(draft *postDraft, opts Options) bool {
+	zonePrompt := promptui.Prompt{
+		Label: "Time zone, e.g. America/New_York (enter for local)",
+		Validate: func(input string) error {
+			_, err := schedule.LoadLocation(strings.TrimSpace(input))
+			return err
+		},
+	}
+
+	zone, err := zonePrompt.Run()
+	if err != nil {
+		fmt.Println(Failed("[ERROR] "), fmt.Errorf("time zone prompt failed: %w", err))
+		return false
+	}
+
+	zone = strings.TrimSpace(zone)
+	loc, _ := schedule.LoadLocation(zone)
+
+	var when time.Time
+
+	atPrompt := promptui.Prompt{
+		Label: "Send at (\"tomorrow 09:00\", \"2025-03-10 14:30\", \"+2h\")",
+		Validate: func(input string) error {
+			at, err := schedule.ParseTime(input, loc, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if !at.After(time.Now()) {
+				return fmt.Errorf("%s is in the past", at.In(loc).Format("Mon Jan 2 15:04 MST"))
+			}
+
+			when = at
+
+			return nil
+		},
+	}
+
+	if _, err := atPrompt.Run(); err != nil {
+		fmt.Println(Failed("[ERROR] "), fmt.Errorf("time prompt failed: %w", err))
+		return false
+	}
+
+	post := &models.ScheduledPost{
+		Profile:      opts.Profile,
+		At:           when,
+		TimeZone:     zone,
+		OutgoingPost: draft.outgoing(),
+	}
+
+	if err := schedule.Add(post); err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+		return false
+	}
+
+	if draft.stored != nil && draft.stored.ID != "" {
+		forgetDraft(draft.stored.ID)
+	}
+
+	fmt.Printf("\U0001F4C5 Post %s scheduled for %s.\n", post.ID, schedule.FormatTime(post.At, post.TimeZone))
+
+	return true
+}
@@ -0,0 +1,43 @@
+package prompt
+
+import (
+	"fmt"
+
+	"x-dev/internal/secrets"
+
+	"github.com/manifoldco/promptui"
+)
+
+const secretOverrideWord = "SEND"
+
+var highlightSecret = promptui.Styler(promptui.BGRed, promptui.FGWhite, promptui.FGBold)
+
+// HighlightSecrets returns text with the possible secrets marked.
+func HighlightSecrets(text string, matches []secrets.Match) string {
+	return secrets.Highlight(text, matches, func(secret string) string {
+		return highlightSecret(secret)
+	})
+}
+
+// confirmSecretOverride asks the user to type a word rather than pick a
+// menu entry, so a secret is never sent by a stray Enter. The caller logs
+// the override.
+func confirmSecretOverride(kinds string) (bool, error) {
+	confirm := promptui.Prompt{
+		Label: fmt.Sprintf("This looks like it contains a secret (%s). Type %s to send it anyway", kinds, secretOverrideWord),
+	}
+
+	answer, err := confirm.Run()
+	if err != nil {
+		return false, fmt.Errorf("override confirmation failed: %w", err)
+	}
+
+	if answer != secretOverrideWord {
+		fmt.Println(Info("[INFO] "), "not overridden.")
+		return false, nil
+	}
+
+	fmt.Println(Warn("[WARN] "), "secret scanner overridden, this is logged.")
+
+	return true, nil
+}
@@ -0,0 +1,57 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"x-dev/internal/spelling"
+
+	"github.com/manifoldco/promptui"
+)
+
+var underlineMisspelling = promptui.Styler(promptui.FGYellow, promptui.FGUnderline)
+
+// underlineMisspellings marks the unknown words of text. The offsets must
+// come from checking text itself, not the draft it was wrapped from.
+func underlineMisspellings(text string, misspellings []spelling.Misspelling) string {
+	var b strings.Builder
+
+	last := 0
+
+	for _, misspelling := range misspellings {
+		b.WriteString(text[last:misspelling.Start])
+		b.WriteString(underlineMisspelling(text[misspelling.Start:misspelling.End]))
+		last = misspelling.End
+	}
+
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+// printMisspellings lists every unknown word once, with suggestions when
+// the dictionary has any.
+func printMisspellings(misspellings []spelling.Misspelling) {
+	if len(misspellings) == 0 {
+		return
+	}
+
+	seen := map[string]bool{}
+
+	for _, misspelling := range misspellings {
+		if seen[misspelling.Word] {
+			continue
+		}
+
+		seen[misspelling.Word] = true
+
+		if len(misspelling.Suggestions) == 0 {
+			fmt.Println(Warn("[WARN] "), fmt.Sprintf("spelling: %q is not in the dictionary", misspelling.Word))
+			continue
+		}
+
+		fmt.Println(Warn("[WARN] "), fmt.Sprintf("spelling: %q, did you mean %s?", misspelling.Word, strings.Join(misspelling.Suggestions, ", ")))
+	}
+
+	fmt.Println("------------------------------------------------------------")
+}
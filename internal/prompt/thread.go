@@ -0,0 +1,488 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"x-dev/internal/api"
+	"x-dev/internal/config"
+	"x-dev/internal/history"
+	"x-dev/internal/links"
+	"x-dev/internal/lint"
+	"x-dev/internal/media"
+	"x-dev/internal/models"
+	"x-dev/internal/secrets"
+	"x-dev/internal/thread"
+	"x-dev/internal/twittertext"
+
+	"github.com/manifoldco/promptui"
+)
+
+func composeThread(ctx context.Context, editor *config.Editor, stored *models.Draft, separator string, maxPostLength int, limits models.MediaLimits) (*models.Thread, error) {
+	fmt.Println(Info("[INFO] "), fmt.Sprintf("separate posts with a line containing only %q", separator))
+
+	content, err := editDraft(ctx, editor, stored, "")
+	if err != nil {
+		return nil, err
+	}
+
+	newThread, err := buildThread(thread.Split(content, separator), maxPostLength, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	newThread.DraftID = stored.ID
+
+	for i := range newThread.Posts {
+		if err := promptAltText(newThread.Posts[i].Attachments); err != nil {
+			return nil, err
+		}
+	}
+
+	return newThread, nil
+}
+
+func buildThread(segments []string, maxPostLength int, limits models.MediaLimits) (*models.Thread, error) {
+	if len(segments) == 0 {
+		return nil, errors.New("no content entered")
+	}
+
+	posts := make([]models.ThreadPost, 0, len(segments))
+
+	var problems []string
+
+	for i, segment := range segments {
+		text, paths := media.ParseAttachHeader(segment)
+		text = strings.TrimSpace(text)
+
+		if length := twittertext.WeightedLength(text); length > maxPostLength {
+			problems = append(problems, fmt.Sprintf("post %d is %d characters (limit %d)", i+1, length, maxPostLength))
+		}
+
+		attachments, err := media.InspectAll(paths, limits)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("post %d: %v", i+1, err))
+		}
+
+		if text == "" && len(attachments) == 0 {
+			problems = append(problems, fmt.Sprintf("post %d is empty", i+1))
+		}
+
+		posts = append(posts, models.ThreadPost{Text: text, Attachments: attachments})
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("thread cannot be sent:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return thread.New(posts), nil
+}
+
+// offerThreadSplit proposes splitting an over-length draft into a thread
+// and lets the user adjust the split in the editor before previewing it.
+// It returns nil when the user declines.
+func offerThreadSplit(ctx context.Context, editor *config.Editor, draft *postDraft, separator string, maxPostLength int, limits models.MediaLimits) (*models.Thread, error) {
+	fmt.Println(Warn("[WARN] "), fmt.Sprintf("post is %d characters, the limit is %d.", twittertext.WeightedLength(draft.text), maxPostLength))
+
+	splitPrompt := promptui.Select{
+		Label: "Split into a thread",
+		Items: []string{"Split into a numbered thread (1/n)", "Split into a thread", "Discard"},
+	}
+
+	index, _, err := splitPrompt.Run()
+	if err != nil {
+		return nil, fmt.Errorf("split selection failed: %w", err)
+	}
+
+	if index == 2 {
+		return nil, nil
+	}
+
+	chunks, err := thread.AutoSplit(draft.text, maxPostLength, index == 0)
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]models.ThreadPost, 0, len(chunks))
+	for _, chunk := range chunks {
+		posts = append(posts, models.ThreadPost{Text: chunk})
+	}
+
+	posts[0].Attachments = draft.attachments
+
+	proposed := thread.New(posts)
+	proposed.DraftID = draft.stored.ID
+	proposed.ReplySettings = draft.replySettings
+
+	for {
+		fmt.Printf("\nProposed split into %d posts:\n", len(proposed.Posts))
+
+		for i, post := range proposed.Posts {
+			fmt.Println("------------------------------------------------------------")
+			fmt.Printf("[%d/%d] %d/%d characters\n", i+1, len(proposed.Posts), twittertext.WeightedLength(post.Text), maxPostLength)
+			fmt.Println(wrapText(post.Text, 60))
+		}
+
+		fmt.Println("------------------------------------------------------------")
+
+		actionPrompt := promptui.Select{
+			Label: "Choose an action",
+			Items: []string{"Continue to preview", "Edit split in editor", "Discard"},
+		}
+
+		_, action, err := actionPrompt.Run()
+		if err != nil {
+			return nil, fmt.Errorf("split action failed: %w", err)
+		}
+
+		switch action {
+		case "Continue to preview":
+			return proposed, nil
+
+		case "Edit split in editor":
+			edited, err := editor.EditContent(ctx, joinThread(proposed, separator))
+			if err != nil {
+				return nil, err
+			}
+
+			rebuilt, err := buildThread(thread.Split(edited, separator), maxPostLength, limits)
+			if err != nil {
+				fmt.Println(Failed("[ERROR] "), err)
+				continue
+			}
+
+			carryOverAltText(proposed, rebuilt)
+			rebuilt.DraftID = proposed.DraftID
+			rebuilt.ReplySettings = proposed.ReplySettings
+			proposed = rebuilt
+
+		default:
+			return nil, nil
+		}
+	}
+}
+
+func joinThread(t *models.Thread, separator string) string {
+	segments := make([]string, 0, len(t.Posts))
+
+	for _, post := range t.Posts {
+		paths := make([]string, 0, len(post.Attachments))
+		for _, attachment := range post.Attachments {
+			paths = append(paths, attachment.Path)
+		}
+
+		segments = append(segments, media.FormatAttachHeader(paths)+post.Text)
+	}
+
+	return strings.Join(segments, "\n"+separator+"\n") + "\n"
+}
+
+func carryOverAltText(from *models.Thread, to *models.Thread) {
+	altTexts := make(map[string]string)
+
+	for _, post := range from.Posts {
+		for _, attachment := range post.Attachments {
+			if attachment.AltText != "" {
+				altTexts[attachment.Path] = attachment.AltText
+			}
+		}
+	}
+
+	for _, post := range to.Posts {
+		for _, attachment := range post.Attachments {
+			if attachment.AltText == "" {
+				attachment.AltText = altTexts[attachment.Path]
+			}
+		}
+	}
+}
+
+func showThreadPreviewPrompt(t *models.Thread, maxPostLength int, opts Options) (int, error) {
+	for {
+		fmt.Printf("\nThread Preview (%d posts):\n", len(t.Posts))
+
+		var (
+			missingAltText int
+			flagged        []int
+			found          [][]secrets.Match
+			blocked        bool
+		)
+
+		for i, post := range t.Posts {
+			var changes []links.Change
+			if post.PostID == "" {
+				t.Posts[i].Text, changes = opts.Links.Rewrite(post.Text)
+				post = t.Posts[i]
+			}
+
+			status := fmt.Sprintf("%d/%d characters", twittertext.WeightedLength(post.Text), maxPostLength)
+			if post.PostID != "" {
+				status = "already posted, ID " + post.PostID
+			}
+
+			fmt.Println("------------------------------------------------------------")
+			fmt.Printf("[%d/%d] %s\n", i+1, len(t.Posts), status)
+			fmt.Println("------------------------------------------------------------")
+
+			var matches []secrets.Match
+			if post.PostID == "" && !t.AllowSecrets {
+				matches = secrets.Scan(post.Text, opts.KnownSecrets)
+			}
+
+			if len(matches) > 0 {
+				fmt.Println(HighlightSecrets(post.Text, matches))
+				fmt.Println(Failed("[ERROR] "), "possible secret:", secrets.Kinds(matches))
+
+				flagged = append(flagged, i)
+				found = append(found, matches)
+			} else {
+				fmt.Println(wrapText(post.Text, 60))
+			}
+
+			printLinkChanges(changes, post.Text)
+
+			if post.PostID == "" {
+				findings := opts.Lint.Run(lint.Post{
+					Text:        post.Text,
+					Attachments: post.Attachments,
+					MaxLength:   maxPostLength,
+					Reply:       i > 0,
+				})
+
+				if PrintFindings(findings) {
+					blocked = true
+				}
+
+				missingAltText += printAttachments(post.Attachments)
+			}
+		}
+
+		fmt.Println("------------------------------------------------------------")
+
+		sendLabel := "Send Thread"
+		if blocked {
+			sendLabel = ""
+		}
+
+		var extraActions []string
+
+		// Only the first post can restrict replies, so once it is live
+		// there is nothing left to choose.
+		if t.Posts[0].PostID == "" {
+			fmt.Println("Who can reply:", replySettingsLabel(t.ReplySettings))
+			fmt.Println("------------------------------------------------------------")
+
+			extraActions = append(extraActions, "Change who can reply")
+		}
+
+		if len(found) > 0 {
+			sendLabel = ""
+			extraActions = append(extraActions, "Send anyway")
+		}
+
+		selection, err := choosePreviewAction(sendLabel, extraActions, missingAltText, opts.AltTextPolicy)
+		if err != nil {
+			return 1, err
+		}
+
+		switch selection {
+		case "Send Thread":
+			return 0, nil
+
+		case "Send anyway":
+			var all []secrets.Match
+			for _, matches := range found {
+				all = append(all, matches...)
+			}
+
+			confirmed, err := confirmSecretOverride(secrets.Kinds(all))
+			if err != nil {
+				return 1, err
+			}
+
+			if !confirmed {
+				continue
+			}
+
+			for j, i := range flagged {
+				if err := secrets.LogOverride(opts.Profile, "prompt", t.Posts[i].Text, found[j]); err != nil {
+					return 1, err
+				}
+			}
+
+			t.AllowSecrets = true
+
+		case "Change who can reply":
+			if t.ReplySettings, err = promptReplySettings(t.ReplySettings); err != nil {
+				return 1, err
+			}
+
+		case "Add alt text":
+			for i := range t.Posts {
+				if t.Posts[i].PostID != "" {
+					continue
+				}
+
+				if err := promptAltText(t.Posts[i].Attachments); err != nil {
+					return 1, err
+				}
+			}
+
+		default:
+			return 1, nil
+		}
+	}
+}
+
+// sendThread posts every unsent entry in order, each replying to the one
+// before it. Progress is persisted after every post so a failure partway
+// through can be resumed instead of leaving half a thread live.
+func sendThread(ctx context.Context, t *models.Thread, accessToken string, opts Options, latestPost *models.LatestPost) {
+	if err := thread.SavePending(t); err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+		return
+	}
+
+	var rateLimit *models.RateLimitInfo
+
+	for i := range t.Posts {
+		post := &t.Posts[i]
+		if post.PostID != "" {
+			continue
+		}
+
+		var sendErr error
+
+		if sendErr = uploadAttachments(ctx, post.Attachments, accessToken, opts.Upload); sendErr == nil {
+			replyPost := &models.ReplyPost{
+				Text:  post.Text,
+				Media: media.MediaIDs(post.Attachments),
+			}
+
+			var parentID string
+
+			if i > 0 {
+				parentID = t.Posts[i-1].PostID
+				replyPost.Reply = &models.Reply{ReplyID: parentID}
+			} else {
+				replyPost.ReplySettings = t.ReplySettings
+			}
+
+			var postResponse *models.PostResponse
+			postResponse, rateLimit, sendErr = api.SendReplyPost(ctx, replyPost, accessToken)
+
+			if sendErr == nil {
+				post.PostID = postResponse.Data.ID
+				fmt.Printf("\U00002705 [%d/%d] Posted! Post ID: %s\n", i+1, len(t.Posts), post.PostID)
+
+				recordHistory(opts, models.HistoryRecord{
+					ID:            post.PostID,
+					Action:        history.ActionFor(parentID, ""),
+					Text:          post.Text,
+					ReplyTo:       parentID,
+					ReplySettings: replyPost.ReplySettings,
+					RateLimit:     rateLimit,
+				})
+
+				latestPost.PostID = post.PostID
+				latestPost.OutboxID = ""
+				latestPost.Text = post.Text
+			}
+		}
+
+		if api.IsUnavailable(sendErr) {
+			fmt.Println(Warn("[WARN] "), fmt.Sprintf("post %d of %d could not reach X:", i+1, len(t.Posts)), sendErr)
+
+			if err := queueThread(t, i, opts, latestPost); err != nil {
+				fmt.Println(Failed("[ERROR] "), "could not save the thread to the outbox:", err)
+			} else {
+				if err := thread.RemovePending(t.ID); err != nil {
+					fmt.Println(Failed("[ERROR] "), err)
+				}
+
+				forgetDraft(t.DraftID)
+
+				return
+			}
+		} else if sendErr != nil {
+			fmt.Println(Failed("[ERROR] "), fmt.Sprintf("post %d of %d failed:", i+1, len(t.Posts)), sendErr)
+		}
+
+		if err := thread.SavePending(t); err != nil {
+			fmt.Println(Failed("[ERROR] "), err)
+		}
+
+		if sendErr != nil {
+			fmt.Println(Warn("[WARN] "), "thread progress saved, choose \"Resume unfinished thread\" to continue it.")
+
+			if rateLimitStatus := rateLimitStatus(rateLimit); rateLimitStatus != "" {
+				fmt.Println(rateLimitStatus)
+			}
+
+			return
+		}
+	}
+
+	if err := thread.RemovePending(t.ID); err != nil {
+		fmt.Println(Failed("[ERROR] "), err)
+	}
+
+	forgetDraft(t.DraftID)
+
+	fmt.Println("\U00002705 Thread Successful!", len(t.Posts), "posts.")
+
+	if rateLimitStatus := rateLimitStatus(rateLimit); rateLimitStatus != "" {
+		fmt.Println(rateLimitStatus)
+	}
+}
+
+func promptPendingThread(pending []*models.Thread) (*models.Thread, bool, error) {
+	items := make([]string, 0, len(pending))
+
+	for _, t := range pending {
+		first := ""
+		if len(t.Posts) > 0 {
+			first = truncate(strings.ReplaceAll(t.Posts[0].Text, "\n", " "), 40)
+		}
+
+		items = append(items, fmt.Sprintf("%s  %d/%d sent  %s",
+			t.UpdatedAt.Format("Jan 2 15:04"), thread.SentCount(t), len(t.Posts), first))
+	}
+
+	threadPrompt := promptui.Select{
+		Label: "Choose a thread",
+		Items: items,
+	}
+
+	index, _, err := threadPrompt.Run()
+	if err != nil {
+		return nil, false, fmt.Errorf("thread selection failed: %w", err)
+	}
+
+	actionPrompt := promptui.Select{
+		Label: "Choose an action",
+		Items: []string{"Resume thread", "Delete saved thread"},
+	}
+
+	_, action, err := actionPrompt.Run()
+	if err != nil {
+		return nil, false, fmt.Errorf("thread action failed: %w", err)
+	}
+
+	selected := pending[index]
+
+	// Media IDs expire, so anything not yet posted is uploaded again.
+	for i := range selected.Posts {
+		if selected.Posts[i].PostID != "" {
+			continue
+		}
+
+		for _, attachment := range selected.Posts[i].Attachments {
+			attachment.MediaID = ""
+		}
+	}
+
+	return selected, action == "Resume thread", nil
+}
@@ -0,0 +1,214 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchDays bounds the search for the next occurrence, so expressions
+// that can never match, such as "0 0 30 2 *", fail instead of looping.
+const cronSearchDays = 5 * 366
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week.
+type Cron struct {
+	minutes, hours, days, months, weekdays uint64
+
+	// When both day fields are restricted, a day matches if either does,
+	// as in classic cron.
+	daysRestricted, weekdaysRestricted bool
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	{name: "day of week", min: 0, max: 7, names: dayNames},
+}
+
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: want 5 fields (minute hour day month weekday)", expr)
+	}
+
+	sets := make([]uint64, len(fields))
+
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+
+		sets[i] = set
+	}
+
+	// Sunday can be written as 0 or 7.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &Cron{
+		minutes:            sets[0],
+		hours:              sets[1],
+		days:               sets[2],
+		months:             sets[3],
+		weekdays:           sets[4],
+		daysRestricted:     !strings.HasPrefix(fields[2], "*"),
+		weekdaysRestricted: !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepPart, spec.name)
+			}
+		}
+
+		low, high := spec.min, spec.max
+
+		if rangePart != "*" {
+			lowPart, highPart, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			if low, err = cronValue(lowPart, spec); err != nil {
+				return 0, err
+			}
+
+			high = low
+
+			if isRange {
+				if high, err = cronValue(highPart, spec); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = spec.max
+			}
+
+			if high < low {
+				return 0, fmt.Errorf("invalid range %q in %s", rangePart, spec.name)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			set |= 1 << value
+		}
+	}
+
+	return set, nil
+}
+
+func cronValue(value string, spec cronField) (int, error) {
+	for i, name := range spec.names {
+		if value == name {
+			return i + spec.min, nil
+		}
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("%s must be between %d and %d, got %q", spec.name, spec.min, spec.max, value)
+	}
+
+	return n, nil
+}
+
+// Next returns the first occurrence after the given instant, reading the
+// expression as wall-clock time in loc. Times skipped by a DST change run
+// at the shifted time, and times repeated by one run only once, at the
+// earlier instant.
+func (c *Cron) Next(after time.Time, loc *time.Location) (time.Time, error) {
+	start := after.In(loc)
+
+	for offset := 0; offset < cronSearchDays; offset++ {
+		// Noon is never inside a DST change, so it is a safe anchor for the day.
+		day := time.Date(start.Year(), start.Month(), start.Day()+offset, 12, 0, 0, 0, loc)
+
+		if !c.matchesDay(day) {
+			continue
+		}
+
+		for hour := 0; hour < 24; hour++ {
+			if c.hours&(1<<hour) == 0 {
+				continue
+			}
+
+			for minute := 0; minute < 60; minute++ {
+				if c.minutes&(1<<minute) == 0 {
+					continue
+				}
+
+				t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+
+				if t.Hour() != hour || t.Minute() != minute {
+					// Inside a DST gap: read the wall clock with the offset in
+					// effect before the change, which lands just past the gap.
+					_, before := t.Add(-12 * time.Hour).Zone()
+					t = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, time.FixedZone("", before)).In(loc)
+				} else if earlier := t.Add(-time.Hour); earlier.Hour() == hour && earlier.Minute() == minute && earlier.Day() == day.Day() {
+					t = earlier
+				}
+
+				if t.After(after) {
+					return t, nil
+				}
+			}
+		}
+	}
+
+	return time.Time{}, errors.New("cron expression never matches")
+}
+
+func (c *Cron) matchesDay(day time.Time) bool {
+	if c.months&(1<<int(day.Month())) == 0 {
+		return false
+	}
+
+	dayOfMonth := c.days&(1<<day.Day()) != 0
+	dayOfWeek := c.weekdays&(1<<int(day.Weekday())) != 0
+
+	if c.daysRestricted && c.weekdaysRestricted {
+		return dayOfMonth || dayOfWeek
+	}
+
+	return dayOfMonth && dayOfWeek
+}
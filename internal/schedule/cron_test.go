@@ -0,0 +1,98 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+
+	// A Saturday.
+	saturday := time.Date(2025, time.March, 8, 18, 0, 0, 0, newYork)
+
+	tests := []struct {
+		expr  string
+		after time.Time
+		want  time.Time
+	}{
+		{"0 9 * * 1", saturday, time.Date(2025, time.March, 10, 9, 0, 0, 0, newYork)},
+		{"0 9 * * MON", saturday, time.Date(2025, time.March, 10, 9, 0, 0, 0, newYork)},
+		{"*/15 * * * *", time.Date(2025, time.March, 10, 10, 7, 0, 0, newYork), time.Date(2025, time.March, 10, 10, 15, 0, 0, newYork)},
+		{"*/15 * * * *", time.Date(2025, time.March, 10, 10, 15, 0, 0, newYork), time.Date(2025, time.March, 10, 10, 30, 0, 0, newYork)},
+		{"0 0 1 * *", saturday, time.Date(2025, time.April, 1, 0, 0, 0, 0, newYork)},
+		{"@weekly", saturday, time.Date(2025, time.March, 9, 0, 0, 0, 0, newYork)},
+		{"0 9 * * 7", saturday, time.Date(2025, time.March, 9, 9, 0, 0, 0, newYork)},
+		{"0 9 * jan,jul mon-fri", saturday, time.Date(2025, time.July, 1, 9, 0, 0, 0, newYork)},
+		{"0 8-18/5 * * *", saturday, time.Date(2025, time.March, 9, 8, 0, 0, 0, newYork)},
+		// Both day fields restricted: the 13th or any Friday, whichever
+		// comes first.
+		{"0 12 13 * 5", time.Date(2025, time.March, 1, 0, 0, 0, 0, newYork), time.Date(2025, time.March, 7, 12, 0, 0, 0, newYork)},
+		{"0 12 13 * 5", time.Date(2025, time.March, 8, 0, 0, 0, 0, newYork), time.Date(2025, time.March, 13, 12, 0, 0, 0, newYork)},
+		// Only the day of month restricted: weekday * does not widen it.
+		{"0 12 13 * *", time.Date(2025, time.March, 1, 0, 0, 0, 0, newYork), time.Date(2025, time.March, 13, 12, 0, 0, 0, newYork)},
+		// 02:30 is skipped when DST starts; the run happens just past the gap.
+		{"30 2 * * *", saturday, time.Date(2025, time.March, 9, 7, 30, 0, 0, time.UTC)},
+		// 01:30 repeats when DST ends; it runs once, at the EDT instant.
+		{"30 1 * * *", time.Date(2025, time.November, 1, 12, 0, 0, 0, newYork), time.Date(2025, time.November, 2, 5, 30, 0, 0, time.UTC)},
+		{"30 1 * * *", time.Date(2025, time.November, 2, 5, 30, 0, 0, time.UTC), time.Date(2025, time.November, 3, 6, 30, 0, 0, time.UTC)},
+		{"30 1 * * *", time.Date(2025, time.November, 2, 6, 0, 0, 0, time.UTC), time.Date(2025, time.November, 3, 6, 30, 0, 0, time.UTC)},
+		{"0 0 29 2 *", saturday, time.Date(2028, time.February, 29, 0, 0, 0, 0, newYork)},
+	}
+
+	for _, test := range tests {
+		cron, err := ParseCron(test.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q) failed: %v", test.expr, err)
+			continue
+		}
+
+		got, err := cron.Next(test.after, newYork)
+		if err != nil {
+			t.Errorf("%q: Next(%s) failed: %v", test.expr, test.after, err)
+			continue
+		}
+
+		if !got.Equal(test.want) {
+			t.Errorf("%q: Next(%s) = %s, want %s", test.expr, test.after, got, test.want.In(newYork))
+		}
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	for _, expr := range []string{"0 0 30 2 *", "0 0 31 4 *"} {
+		cron, err := ParseCron(expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) failed: %v", expr, err)
+		}
+
+		if got, err := cron.Next(time.Now(), time.UTC); err == nil {
+			t.Errorf("%q: Next = %s, want an error", expr, got)
+		}
+	}
+}
+
+func TestParseCronRejects(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"0 9 * * fri-mon",
+		"0 9 * * funday",
+		"@fortnightly",
+	}
+
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded", expr)
+		}
+	}
+}
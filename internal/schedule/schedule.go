@@ -0,0 +1,310 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+const queueFile = "schedule.json"
+
+// recurringPrefix marks the IDs of recurring entries so both kinds can be
+// addressed by the same commands.
+const recurringPrefix = "r"
+
+type queue struct {
+	NextID    int                     `json:"next_id"`
+	Posts     []*models.ScheduledPost `json:"posts"`
+	Recurring []*models.RecurringPost `json:"recurring,omitempty"`
+}
+
+var queueMu sync.Mutex
+
+func Add(post *models.ScheduledPost) error {
+	return update(func(q *queue) error {
+		q.NextID++
+
+		post.ID = strconv.Itoa(q.NextID)
+		post.Status = models.ScheduleStatusPending
+		post.CreatedAt = time.Now()
+
+		q.Posts = append(q.Posts, post)
+
+		return nil
+	})
+}
+
+// List returns scheduled posts ordered by due time. Finished entries are
+// included only when all is set; one being sent is always listed.
+func List(all bool) ([]*models.ScheduledPost, error) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	q, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	var posts []*models.ScheduledPost
+
+	for _, post := range q.Posts {
+		if all || post.Status == models.ScheduleStatusPending || post.Status == models.ScheduleStatusSending {
+			posts = append(posts, post)
+		}
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].At.Before(posts[j].At)
+	})
+
+	return posts, nil
+}
+
+func Get(id string) (*models.ScheduledPost, error) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	q, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range q.Posts {
+		if post.ID == id {
+			return post, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no scheduled post with ID %s", id)
+}
+
+// Edit stores the changes to post, queueing it again. It checks the stored
+// entry rather than post, as the scheduler may have sent it while the
+// user was editing.
+func Edit(post *models.ScheduledPost) error {
+	return update(func(q *queue) error {
+		for i, stored := range q.Posts {
+			if stored.ID != post.ID {
+				continue
+			}
+
+			switch stored.Status {
+			case models.ScheduleStatusSent:
+				return fmt.Errorf("post %s was already sent as %s", stored.ID, stored.PostID)
+			case models.ScheduleStatusSending:
+				return fmt.Errorf("post %s is being sent", stored.ID)
+			}
+
+			post.Status = models.ScheduleStatusPending
+			post.Attempts = 0
+			post.Error = ""
+
+			q.Posts[i] = post
+
+			return nil
+		}
+
+		return fmt.Errorf("no scheduled post with ID %s", post.ID)
+	})
+}
+
+// Claim marks the entry with id as being sent and returns it, so an edit
+// or a second scheduler cannot change or send it meanwhile. It reports
+// false when the entry was removed, edited to a later time or is no longer
+// pending.
+func Claim(id string, now time.Time) (*models.ScheduledPost, bool, error) {
+	var claimed *models.ScheduledPost
+
+	err := update(func(q *queue) error {
+		for _, stored := range q.Posts {
+			if stored.ID == id && stored.Status == models.ScheduleStatusPending && !stored.At.After(now) {
+				stored.Status = models.ScheduleStatusSending
+				claimed = stored
+
+				return nil
+			}
+		}
+
+		return nil
+	})
+	if err != nil || claimed == nil {
+		return nil, false, err
+	}
+
+	return claimed, true, nil
+}
+
+// Finish records the outcome of a claimed entry. Only the fields a send
+// changes are written, so the stored entry keeps everything else. An entry
+// removed while it was sent stays removed.
+func Finish(post *models.ScheduledPost) error {
+	return update(func(q *queue) error {
+		for _, stored := range q.Posts {
+			if stored.ID == post.ID {
+				stored.Status = post.Status
+				stored.Attempts = post.Attempts
+				stored.PostID = post.PostID
+				stored.SentAt = post.SentAt
+				stored.Error = post.Error
+
+				return nil
+			}
+		}
+
+		return nil
+	})
+}
+
+// IsRecurring reports whether id names a recurring entry.
+func IsRecurring(id string) bool {
+	return strings.HasPrefix(id, recurringPrefix)
+}
+
+func Remove(id string) error {
+	return update(func(q *queue) error {
+		for i := range q.Recurring {
+			if q.Recurring[i].ID == id {
+				q.Recurring = append(q.Recurring[:i], q.Recurring[i+1:]...)
+				return nil
+			}
+		}
+
+		for i := range q.Posts {
+			if q.Posts[i].ID == id {
+				q.Posts = append(q.Posts[:i], q.Posts[i+1:]...)
+				return nil
+			}
+		}
+
+		return fmt.Errorf("no scheduled post with ID %s", id)
+	})
+}
+
+// Due returns the pending posts whose time has come.
+func Due(now time.Time) ([]*models.ScheduledPost, error) {
+	pending, err := List(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []*models.ScheduledPost
+
+	for _, post := range pending {
+		if !post.At.After(now) {
+			due = append(due, post)
+		}
+	}
+
+	return due, nil
+}
+
+// AddRecurring stores a recurring entry. Its Next time must already be set.
+func AddRecurring(post *models.RecurringPost) error {
+	return update(func(q *queue) error {
+		q.NextID++
+
+		post.ID = recurringPrefix + strconv.Itoa(q.NextID)
+		post.CreatedAt = time.Now()
+
+		q.Recurring = append(q.Recurring, post)
+
+		return nil
+	})
+}
+
+// ListRecurring returns recurring entries ordered by their next run.
+func ListRecurring() ([]*models.RecurringPost, error) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	q, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	posts := append([]*models.RecurringPost(nil), q.Recurring...)
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].Next.Before(posts[j].Next)
+	})
+
+	return posts, nil
+}
+
+func GetRecurring(id string) (*models.RecurringPost, error) {
+	posts, err := ListRecurring()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		if post.ID == id {
+			return post, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no recurring post with ID %s", id)
+}
+
+func UpdateRecurring(post *models.RecurringPost) error {
+	return update(func(q *queue) error {
+		for i := range q.Recurring {
+			if q.Recurring[i].ID == post.ID {
+				q.Recurring[i] = post
+				return nil
+			}
+		}
+
+		return fmt.Errorf("no recurring post with ID %s", post.ID)
+	})
+}
+
+// DueRecurring returns the recurring entries whose next run has come.
+func DueRecurring(now time.Time) ([]*models.RecurringPost, error) {
+	posts, err := ListRecurring()
+	if err != nil {
+		return nil, err
+	}
+
+	var due []*models.RecurringPost
+
+	for _, post := range posts {
+		if !post.Next.After(now) {
+			due = append(due, post)
+		}
+	}
+
+	return due, nil
+}
+
+func update(change func(*queue) error) error {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	q, err := load()
+	if err != nil {
+		return err
+	}
+
+	if err := change(q); err != nil {
+		return err
+	}
+
+	return store.Save(queueFile, q)
+}
+
+func load() (*queue, error) {
+	q := &queue{}
+
+	if _, err := store.Load(queueFile, q); err != nil {
+		return nil, fmt.Errorf("failed to load schedule: %w", err)
+	}
+
+	return q, nil
+}
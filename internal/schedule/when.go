@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+var (
+	relativePattern = regexp.MustCompile(`^(?:\+|in\s+)(.+)$`)
+	dayPattern      = regexp.MustCompile(`^(\d+)d(.*)$`)
+	clockPattern    = regexp.MustCompile(`^(today|tomorrow)?\s*(\d{1,2}):(\d{2})$`)
+)
+
+var absoluteLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// ParseTime resolves input to an instant. It accepts relative times ("+90m",
+// "in 2h30m", "in 1d"), wall-clock times in loc ("2025-03-10 09:00",
+// "tomorrow 09:00", "17:30") and RFC 3339 timestamps with their own offset.
+// Wall-clock times that do not exist in loc because of a DST change are
+// rejected; ambiguous ones resolve to the earlier instant.
+func ParseTime(input string, loc *time.Location, now time.Time) (time.Time, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return time.Time{}, errors.New("a time is required")
+	}
+
+	if match := relativePattern.FindStringSubmatch(input); match != nil {
+		offset, err := parseOffset(strings.ReplaceAll(match[1], " ", ""))
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return now.Add(offset), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(input)); err == nil {
+		return t, nil
+	}
+
+	if match := clockPattern.FindStringSubmatch(input); match != nil {
+		hour, _ := strconv.Atoi(match[2])
+		minute, _ := strconv.Atoi(match[3])
+
+		day := now.In(loc)
+		if match[1] == "tomorrow" {
+			day = day.AddDate(0, 0, 1)
+		}
+
+		t, err := wallClock(day.Year(), day.Month(), day.Day(), hour, minute, 0, loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		// A bare clock time that already passed today means tomorrow.
+		if match[1] == "" && !t.After(now) {
+			day = day.AddDate(0, 0, 1)
+			return wallClock(day.Year(), day.Month(), day.Day(), hour, minute, 0, loc)
+		}
+
+		return t, nil
+	}
+
+	for _, layout := range absoluteLayouts {
+		parsed, err := time.Parse(layout, strings.ToUpper(input))
+		if err != nil {
+			continue
+		}
+
+		return wallClock(parsed.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), loc)
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognised time %q, use e.g. \"2025-03-10 09:00\", \"tomorrow 09:00\" or \"+2h\"", input)
+}
+
+func parseOffset(value string) (time.Duration, error) {
+	var offset time.Duration
+
+	if match := dayPattern.FindStringSubmatch(value); match != nil {
+		days, _ := strconv.Atoi(match[1])
+		offset = time.Duration(days) * 24 * time.Hour
+		value = match[2]
+	}
+
+	if value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid relative time: %w", err)
+		}
+
+		offset += duration
+	}
+
+	if offset <= 0 {
+		return 0, errors.New("relative time must be in the future")
+	}
+
+	return offset, nil
+}
+
+// wallClock builds the instant for a local wall-clock time, refusing times
+// that fall into a DST gap instead of silently shifting them.
+func wallClock(year int, month time.Month, day, hour, minute, second int, loc *time.Location) (time.Time, error) {
+	t := time.Date(year, month, day, hour, minute, second, 0, loc)
+
+	if t.Hour() != hour || t.Minute() != minute || t.Day() != day {
+		return time.Time{}, fmt.Errorf("%04d-%02d-%02d %02d:%02d does not exist in %s because of a daylight saving change",
+			year, month, day, hour, minute, loc)
+	}
+
+	if earlier := t.Add(-time.Hour); earlier.Hour() == hour && earlier.Minute() == minute {
+		return earlier, nil
+	}
+
+	return t, nil
+}
+
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || strings.EqualFold(name, "local") {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+
+	return loc, nil
+}
+
+// FormatTime shows an instant in the time zone it was scheduled in, along
+// with how far away it is.
+func FormatTime(at time.Time, timeZone string) string {
+	loc, err := LoadLocation(timeZone)
+	if err != nil {
+		loc = time.Local
+	}
+
+	return fmt.Sprintf("%s (%s)", at.In(loc).Format("Mon Jan 2 2006 15:04 MST"), humanize.Time(at))
+}
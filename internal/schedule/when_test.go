@@ -0,0 +1,97 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loc
+}
+
+func TestParseTime(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	now := time.Date(2025, time.March, 8, 18, 0, 0, 0, newYork)
+
+	tests := []struct {
+		input string
+		loc   *time.Location
+		want  time.Time
+	}{
+		{"+90m", newYork, now.Add(90 * time.Minute)},
+		{"in 2h30m", newYork, now.Add(150 * time.Minute)},
+		{"in 1d", newYork, now.Add(24 * time.Hour)},
+		{"+1d 2h", newYork, now.Add(26 * time.Hour)},
+		{"2025-03-10T09:00:00Z", newYork, time.Date(2025, time.March, 10, 9, 0, 0, 0, time.UTC)},
+		{"2025-03-10t09:00:00+01:00", newYork, time.Date(2025, time.March, 10, 8, 0, 0, 0, time.UTC)},
+		{"2025-03-10 09:00", newYork, time.Date(2025, time.March, 10, 13, 0, 0, 0, time.UTC)},
+		{"2025-03-10T09:00:30", newYork, time.Date(2025, time.March, 10, 13, 0, 30, 0, time.UTC)},
+		{"2025-03-10 09:00", time.UTC, time.Date(2025, time.March, 10, 9, 0, 0, 0, time.UTC)},
+		{"19:15", newYork, time.Date(2025, time.March, 8, 19, 15, 0, 0, newYork)},
+		{"today 19:15", newYork, time.Date(2025, time.March, 8, 19, 15, 0, 0, newYork)},
+		// A clock time that already passed today means tomorrow, which here
+		// is the day DST starts.
+		{"09:00", newYork, time.Date(2025, time.March, 9, 13, 0, 0, 0, time.UTC)},
+		{"Tomorrow 09:00", newYork, time.Date(2025, time.March, 9, 13, 0, 0, 0, time.UTC)},
+		// 01:30 happens twice when DST ends; the earlier, EDT one is used.
+		{"2025-11-02 01:30", newYork, time.Date(2025, time.November, 2, 5, 30, 0, 0, time.UTC)},
+		{"2025-11-02 00:30", newYork, time.Date(2025, time.November, 2, 4, 30, 0, 0, time.UTC)},
+		{"2025-11-02 02:30", newYork, time.Date(2025, time.November, 2, 7, 30, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		got, err := ParseTime(test.input, test.loc, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q) failed: %v", test.input, err)
+			continue
+		}
+
+		if !got.Equal(test.want) {
+			t.Errorf("ParseTime(%q) = %s, want %s", test.input, got.UTC(), test.want.UTC())
+		}
+	}
+}
+
+func TestParseTimeRejects(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	now := time.Date(2025, time.March, 8, 18, 0, 0, 0, newYork)
+
+	tests := []string{
+		"",
+		"   ",
+		"+0m",
+		"in -5m",
+		"+soon",
+		"next tuesday",
+		"25:00",
+		"2025-13-01 09:00",
+		// 02:30 does not exist on the day DST starts.
+		"2025-03-09 02:30",
+		"tomorrow 02:00",
+	}
+
+	for _, input := range tests {
+		if got, err := ParseTime(input, newYork, now); err == nil {
+			t.Errorf("ParseTime(%q) = %s, want an error", input, got)
+		}
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	for _, name := range []string{"", "local", "Local"} {
+		if loc := mustLoad(t, name); loc != time.Local {
+			t.Errorf("LoadLocation(%q) = %s, want Local", name, loc)
+		}
+	}
+
+	if _, err := LoadLocation("Mars/Olympus_Mons"); err == nil {
+		t.Error("LoadLocation accepted an unknown zone")
+	}
+}
@@ -0,0 +1,262 @@
+package secrets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+	"x-dev/internal/twittertext"
+)
+
+const (
+	overrideLog = "secret-overrides.jsonl"
+
+	// minKnownLength keeps short configured values, which would match
+	// ordinary words, out of the literal search.
+	minKnownLength = 8
+
+	minEntropyLength = 20
+	minEntropy       = 4.0
+
+	// minSwitchRatio is how often, at least, neighbouring characters of a
+	// random-looking token change between upper case, lower case and digits.
+	// Generated keys change most of the time, CamelCase names rarely.
+	minSwitchRatio = 0.4
+)
+
+type Match struct {
+	Kind  string
+	Start int
+	End   int
+}
+
+// FoundError is returned instead of sending a post that looks like it
+// contains a secret.
+type FoundError struct {
+	Text    string
+	Matches []Match
+}
+
+func (e *FoundError) Error() string {
+	return fmt.Sprintf("post looks like it contains a secret: %s", Kinds(e.Matches))
+}
+
+var patterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	{"AWS secret access key", regexp.MustCompile(`(?i)aws_?secret_?access_?key\s*[=:]\s*["']?[A-Za-z0-9/+=]{40}`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Slack webhook", regexp.MustCompile(`https://hooks\.slack\.com/services/T[A-Za-z0-9_]+/B[A-Za-z0-9_]+/[A-Za-z0-9_]+`)},
+	{"JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY(?: BLOCK)?-----`)},
+}
+
+var candidatePattern = regexp.MustCompile(`[A-Za-z0-9+/=_-]{20,}`)
+
+// Scan looks for credentials in text: known token formats, the literal
+// values in known, such as x-yapper's own client secret and tokens, and
+// long random-looking strings. Overlapping matches are reported once.
+func Scan(text string, known []string) []Match {
+	var matches []Match
+
+	for _, p := range patterns {
+		for _, loc := range p.pattern.FindAllStringIndex(text, -1) {
+			matches = append(matches, Match{Kind: p.kind, Start: loc[0], End: loc[1]})
+		}
+	}
+
+	for _, value := range known {
+		if len(value) < minKnownLength {
+			continue
+		}
+
+		for offset := 0; ; {
+			index := strings.Index(text[offset:], value)
+			if index < 0 {
+				break
+			}
+
+			start := offset + index
+			matches = append(matches, Match{Kind: "x-yapper credential", Start: start, End: start + len(value)})
+			offset = start + len(value)
+		}
+	}
+
+	// Links often carry long random IDs, so they are left out of the
+	// entropy check.
+	blanked := []byte(text)
+	for _, span := range twittertext.ExtractURLs(text) {
+		for i := span.Start; i < span.End; i++ {
+			blanked[i] = ' '
+		}
+	}
+
+	for _, loc := range candidatePattern.FindAllIndex(blanked, -1) {
+		if randomLooking(text[loc[0]:loc[1]]) {
+			matches = append(matches, Match{Kind: "high-entropy string", Start: loc[0], End: loc[1]})
+		}
+	}
+
+	return merge(matches)
+}
+
+// Kinds lists the kinds of secrets found, without repeats.
+func Kinds(matches []Match) string {
+	var kinds []string
+
+	seen := map[string]bool{}
+
+	for _, match := range matches {
+		if !seen[match.Kind] {
+			seen[match.Kind] = true
+			kinds = append(kinds, match.Kind)
+		}
+	}
+
+	return strings.Join(kinds, ", ")
+}
+
+// Highlight returns text with every match passed through mark.
+func Highlight(text string, matches []Match, mark func(string) string) string {
+	var b strings.Builder
+
+	last := 0
+
+	for _, match := range matches {
+		b.WriteString(text[last:match.Start])
+		b.WriteString(mark(text[match.Start:match.End]))
+		last = match.End
+	}
+
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+// LogOverride records that a post was sent, or queued, despite matches. Only
+// the kind and a redacted form of each match are written, never the secret.
+func LogOverride(profile string, source string, text string, matches []Match) error {
+	entry := models.SecretOverride{
+		Time:    time.Now(),
+		Profile: profile,
+		Source:  source,
+	}
+
+	for _, match := range matches {
+		entry.Matches = append(entry.Matches, models.SecretMatch{
+			Kind:     match.Kind,
+			Redacted: redact(text[match.Start:match.End]),
+		})
+	}
+
+	if err := store.Append(overrideLog, entry); err != nil {
+		return fmt.Errorf("failed to log secret override: %w", err)
+	}
+
+	return nil
+}
+
+func redact(secret string) string {
+	runes := []rune(secret)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+
+	return string(runes[:4]) + strings.Repeat("*", len(runes)-4)
+}
+
+// randomLooking reports whether a long token mixes upper and lower case
+// letters and digits, switching between them often, with a high Shannon
+// entropy, like generated keys do and identifiers or words do not.
+func randomLooking(token string) bool {
+	if len(token) < minEntropyLength {
+		return false
+	}
+
+	var (
+		upper, lower, digit bool
+		switches            int
+		previous            rune
+	)
+
+	counts := map[rune]int{}
+
+	for i, r := range token {
+		counts[r]++
+
+		class := charClass(r)
+
+		switch class {
+		case 'A':
+			upper = true
+		case 'a':
+			lower = true
+		case '0':
+			digit = true
+		}
+
+		if i > 0 && class != previous {
+			switches++
+		}
+
+		previous = class
+	}
+
+	if !upper || !lower || !digit || float64(switches)/float64(len(token)-1) < minSwitchRatio {
+		return false
+	}
+
+	entropy := 0.0
+	length := float64(len(token))
+
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy >= minEntropy
+}
+
+func charClass(r rune) rune {
+	switch {
+	case unicode.IsUpper(r):
+		return 'A'
+	case unicode.IsLower(r):
+		return 'a'
+	case unicode.IsDigit(r):
+		return '0'
+	default:
+		return '_'
+	}
+}
+
+func merge(matches []Match) []Match {
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+
+	var merged []Match
+
+	for _, match := range matches {
+		if n := len(merged); n > 0 && match.Start < merged[n-1].End {
+			if match.End > merged[n-1].End {
+				merged[n-1].End = match.End
+			}
+
+			continue
+		}
+
+		merged = append(merged, match)
+	}
+
+	return merged
+}
@@ -0,0 +1,652 @@
+package spelling
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultTry is used for suggestions when the .aff file has no TRY line.
+const defaultTry = "esianrtolcdugmphbyfvkwzESIANRTOLCDUGMPHBYFVKWZ'"
+
+// affix is one PFX or SFX rule: strip is removed from the stem and add put
+// in its place, if the stem matches condition.
+type affix struct {
+	flag      string
+	cross     bool
+	strip     string
+	add       string
+	condition *regexp.Regexp
+}
+
+// Dictionary is a Hunspell dictionary loaded from an .aff and a .dic file.
+// It covers plain words, prefixes, suffixes and their cross products, which
+// is what most dictionaries rely on; compound words are not supported.
+type Dictionary struct {
+	words    map[string][][]string
+	prefixes map[string][]*affix
+	suffixes map[string][]*affix
+
+	flagMode string
+	aliases  [][]string
+	try      string
+	rep      [][2]string
+	ignore   string
+
+	forbidden      string
+	noSuggest      string
+	needAffix      string
+	onlyInCompound string
+}
+
+// Load reads a Hunspell dictionary. The encoding named by SET in the .aff
+// file applies to both files; UTF-8, ISO8859-1 and ISO8859-15 are read.
+func Load(affPath string, dicPath string) (*Dictionary, error) {
+	affData, err := os.ReadFile(affPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %w", err)
+	}
+
+	encoding := ""
+
+	for _, line := range strings.Split(string(affData), "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "SET" {
+			encoding = fields[1]
+			break
+		}
+	}
+
+	aff, err := decode(affData, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", affPath, err)
+	}
+
+	d := &Dictionary{
+		words:    map[string][][]string{},
+		prefixes: map[string][]*affix{},
+		suffixes: map[string][]*affix{},
+		try:      defaultTry,
+	}
+
+	if err := d.parseAff(aff); err != nil {
+		return nil, fmt.Errorf("%s: %w", affPath, err)
+	}
+
+	dicData, err := os.ReadFile(dicPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dictionary: %w", err)
+	}
+
+	dic, err := decode(dicData, encoding)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", dicPath, err)
+	}
+
+	d.parseDic(dic)
+
+	return d, nil
+}
+
+func (d *Dictionary) parseAff(aff string) error {
+	// remaining counts the rules still expected after each PFX/SFX header.
+	remaining := map[string]int{}
+	cross := map[string]bool{}
+	aliasHeader := false
+
+	for number, line := range strings.Split(aff, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "FLAG":
+			d.flagMode = fields[1]
+
+		case "TRY":
+			d.try = fields[1]
+
+		case "IGNORE":
+			d.ignore = fields[1]
+
+		case "REP":
+			if len(fields) >= 3 {
+				d.rep = append(d.rep, [2]string{
+					strings.ReplaceAll(fields[1], "_", " "),
+					strings.ReplaceAll(fields[2], "_", " "),
+				})
+			}
+
+		case "AF":
+			// The first AF line gives the number of aliases.
+			if !aliasHeader {
+				aliasHeader = true
+				continue
+			}
+
+			d.aliases = append(d.aliases, d.splitFlags(fields[1]))
+
+		case "FORBIDDENWORD":
+			d.forbidden = d.singleFlag(fields[1])
+
+		case "NOSUGGEST":
+			d.noSuggest = d.singleFlag(fields[1])
+
+		case "NEEDAFFIX", "PSEUDOROOT":
+			d.needAffix = d.singleFlag(fields[1])
+
+		case "ONLYINCOMPOUND":
+			d.onlyInCompound = d.singleFlag(fields[1])
+
+		case "PFX", "SFX":
+			key := fields[0] + " " + fields[1]
+
+			if remaining[key] == 0 {
+				if len(fields) < 4 {
+					return fmt.Errorf("line %d: incomplete %s header", number+1, fields[0])
+				}
+
+				count, err := strconv.Atoi(fields[3])
+				if err != nil {
+					return fmt.Errorf("line %d: invalid %s rule count %q", number+1, fields[0], fields[3])
+				}
+
+				remaining[key] = count
+				cross[key] = fields[2] == "Y"
+
+				continue
+			}
+
+			remaining[key]--
+
+			rule, err := d.parseAffix(fields, fields[0] == "PFX")
+			if err != nil {
+				return fmt.Errorf("line %d: %w", number+1, err)
+			}
+
+			rule.cross = cross[key]
+
+			if fields[0] == "PFX" {
+				d.prefixes[rule.add] = append(d.prefixes[rule.add], rule)
+			} else {
+				d.suffixes[rule.add] = append(d.suffixes[rule.add], rule)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *Dictionary) parseAffix(fields []string, prefix bool) (*affix, error) {
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("incomplete %s rule", fields[0])
+	}
+
+	rule := &affix{flag: d.singleFlag(fields[1]), strip: fields[2]}
+
+	if rule.strip == "0" {
+		rule.strip = ""
+	}
+
+	// Continuation flags after a slash are for twofold affixes and
+	// compounds, which are not supported.
+	rule.add, _, _ = strings.Cut(fields[3], "/")
+
+	if rule.add == "0" {
+		rule.add = ""
+	}
+
+	rule.add = d.removeIgnored(rule.add)
+
+	condition := "."
+	if len(fields) >= 5 {
+		condition = fields[4]
+	}
+
+	if condition != "." {
+		pattern := conditionPattern(condition)
+		if prefix {
+			pattern = "^(?:" + pattern + ")"
+		} else {
+			pattern = "(?:" + pattern + ")$"
+		}
+
+		var err error
+		if rule.condition, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %w", condition, err)
+		}
+	}
+
+	return rule, nil
+}
+
+// conditionPattern turns an affix condition, which only knows characters,
+// "." and bracket classes, into a regular expression.
+func conditionPattern(condition string) string {
+	var b strings.Builder
+
+	inClass := false
+
+	for _, r := range condition {
+		switch {
+		case r == '[' && !inClass:
+			inClass = true
+			b.WriteRune(r)
+		case r == ']' && inClass:
+			inClass = false
+			b.WriteRune(r)
+		case r == '^' && inClass:
+			b.WriteRune(r)
+		case r == '.' && !inClass:
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	return b.String()
+}
+
+func (d *Dictionary) parseDic(dic string) {
+	for i, line := range strings.Split(dic, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// The first line is the approximate word count, and indented lines
+		// are comments in some dictionaries.
+		if i == 0 || line == "" || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+
+		entry, _, _ := strings.Cut(line, "\t")
+
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+
+		word, flags := splitEntry(fields[0])
+		word = d.removeIgnored(word)
+
+		d.words[word] = append(d.words[word], d.parseFlags(flags))
+	}
+}
+
+// splitEntry separates a .dic entry at the first slash that is not
+// escaped with a backslash.
+func splitEntry(entry string) (string, string) {
+	for i := 0; i < len(entry); i++ {
+		switch entry[i] {
+		case '\\':
+			i++
+		case '/':
+			if i > 0 {
+				return strings.ReplaceAll(entry[:i], `\/`, "/"), entry[i+1:]
+			}
+		}
+	}
+
+	return strings.ReplaceAll(entry, `\/`, "/"), ""
+}
+
+// parseFlags reads the flags of a word or affix, which are a number
+// standing for an AF line when the dictionary uses aliases.
+func (d *Dictionary) parseFlags(value string) []string {
+	if len(d.aliases) > 0 {
+		if index, err := strconv.Atoi(value); err == nil && index >= 1 && index <= len(d.aliases) {
+			return d.aliases[index-1]
+		}
+	}
+
+	return d.splitFlags(value)
+}
+
+func (d *Dictionary) splitFlags(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var flags []string
+
+	switch d.flagMode {
+	case "long":
+		runes := []rune(value)
+		for i := 0; i+1 < len(runes); i += 2 {
+			flags = append(flags, string(runes[i:i+2]))
+		}
+
+	case "num":
+		for _, flag := range strings.Split(value, ",") {
+			flags = append(flags, strings.TrimSpace(flag))
+		}
+
+	default:
+		for _, r := range value {
+			flags = append(flags, string(r))
+		}
+	}
+
+	return flags
+}
+
+func (d *Dictionary) singleFlag(value string) string {
+	if flags := d.splitFlags(value); len(flags) > 0 {
+		return flags[0]
+	}
+
+	return ""
+}
+
+func (d *Dictionary) removeIgnored(word string) string {
+	if d.ignore == "" {
+		return word
+	}
+
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(d.ignore, r) {
+			return -1
+		}
+
+		return r
+	}, word)
+}
+
+// Check reports whether word is spelled correctly. A capitalized word also
+// matches its lower-case form and an upper-case one any form.
+func (d *Dictionary) Check(word string) bool {
+	return d.check(word, false)
+}
+
+func (d *Dictionary) check(word string, suggesting bool) bool {
+	word = d.removeIgnored(word)
+	if word == "" {
+		return true
+	}
+
+	for _, flags := range d.words[word] {
+		if d.forbidden != "" && hasFlag(flags, d.forbidden) {
+			return false
+		}
+	}
+
+	for _, variant := range caseVariants(word) {
+		if d.lookup(variant, suggesting) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *Dictionary) lookup(word string, suggesting bool) bool {
+	if d.validStem(word, nil, suggesting) {
+		return true
+	}
+
+	for i := 0; i <= len(word); i++ {
+		if i < len(word) && !utf8.RuneStart(word[i]) {
+			continue
+		}
+
+		for _, sfx := range d.suffixes[word[i:]] {
+			stem := word[:i] + sfx.strip
+			if stem == "" || (sfx.condition != nil && !sfx.condition.MatchString(stem)) {
+				continue
+			}
+
+			if d.validStem(stem, []string{sfx.flag}, suggesting) {
+				return true
+			}
+		}
+
+		for _, pfx := range d.prefixes[word[:i]] {
+			stem := pfx.strip + word[i:]
+			if stem == "" || (pfx.condition != nil && !pfx.condition.MatchString(stem)) {
+				continue
+			}
+
+			if d.validStem(stem, []string{pfx.flag}, suggesting) {
+				return true
+			}
+
+			if pfx.cross && d.crossSuffix(stem, pfx.flag, suggesting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// crossSuffix looks for a suffix on a word whose prefix was already
+// removed; the stem must allow both.
+func (d *Dictionary) crossSuffix(word string, prefixFlag string, suggesting bool) bool {
+	for i := 0; i < len(word); i++ {
+		if !utf8.RuneStart(word[i]) {
+			continue
+		}
+
+		for _, sfx := range d.suffixes[word[i:]] {
+			if !sfx.cross {
+				continue
+			}
+
+			stem := word[:i] + sfx.strip
+			if stem == "" || (sfx.condition != nil && !sfx.condition.MatchString(stem)) {
+				continue
+			}
+
+			if d.validStem(stem, []string{prefixFlag, sfx.flag}, suggesting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// validStem reports whether stem is in the dictionary with all of required.
+// A stem with NEEDAFFIX only counts when an affix was removed.
+func (d *Dictionary) validStem(stem string, required []string, suggesting bool) bool {
+	for _, flags := range d.words[stem] {
+		switch {
+		case d.forbidden != "" && hasFlag(flags, d.forbidden),
+			d.onlyInCompound != "" && hasFlag(flags, d.onlyInCompound),
+			len(required) == 0 && d.needAffix != "" && hasFlag(flags, d.needAffix),
+			suggesting && d.noSuggest != "" && hasFlag(flags, d.noSuggest):
+			continue
+		}
+
+		matches := true
+
+		for _, flag := range required {
+			if !hasFlag(flags, flag) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Suggest returns up to limit correctly spelled words one edit away from
+// word, such as a swapped, missing, extra or wrong letter, in the case
+// word was written in.
+func (d *Dictionary) Suggest(word string, limit int) []string {
+	var suggestions []string
+
+	tried := map[string]bool{word: true}
+
+	// suggested is kept in lower case, TRY lists upper-case letters too and
+	// "Do" is no better a suggestion than "do".
+	suggested := map[string]bool{}
+
+	add := func(candidate string) bool {
+		if tried[candidate] || suggested[strings.ToLower(candidate)] {
+			return false
+		}
+
+		tried[candidate] = true
+
+		if before, after, split := strings.Cut(candidate, " "); split {
+			if !d.check(before, true) || !d.check(after, true) {
+				return false
+			}
+		} else if !d.check(candidate, true) {
+			return false
+		}
+
+		suggested[strings.ToLower(candidate)] = true
+		suggestions = append(suggestions, matchCase(candidate, word))
+
+		return len(suggestions) >= limit
+	}
+
+	for _, candidate := range d.candidates(strings.ToLower(word)) {
+		if add(candidate) {
+			break
+		}
+	}
+
+	return suggestions
+}
+
+// candidates lists the edits of word in the order they are tried, the more
+// likely mistakes first.
+func (d *Dictionary) candidates(word string) []string {
+	var candidates []string
+
+	for _, rep := range d.rep {
+		for offset := 0; ; {
+			index := strings.Index(word[offset:], rep[0])
+			if index < 0 {
+				break
+			}
+
+			start := offset + index
+			candidates = append(candidates, word[:start]+rep[1]+word[start+len(rep[0]):])
+			offset = start + len(rep[0])
+		}
+	}
+
+	runes := []rune(word)
+	candidates = append(candidates, capitalize(word))
+
+	for i := 0; i+1 < len(runes); i++ {
+		swapped := append([]rune{}, runes...)
+		swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+		candidates = append(candidates, string(swapped))
+	}
+
+	for i := range runes {
+		for _, r := range d.try {
+			if r != runes[i] {
+				candidates = append(candidates, string(runes[:i])+string(r)+string(runes[i+1:]))
+			}
+		}
+	}
+
+	for i := range runes {
+		candidates = append(candidates, string(runes[:i])+string(runes[i+1:]))
+	}
+
+	for i := 0; i <= len(runes); i++ {
+		for _, r := range d.try {
+			candidates = append(candidates, string(runes[:i])+string(r)+string(runes[i:]))
+		}
+	}
+
+	for i := 1; i < len(runes); i++ {
+		candidates = append(candidates, string(runes[:i])+" "+string(runes[i:]))
+	}
+
+	return candidates
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// caseVariants returns the forms of word to look up: as written, then the
+// forms it may take at the start of a sentence or in all caps.
+func caseVariants(word string) []string {
+	lower := strings.ToLower(word)
+
+	switch {
+	case word == lower:
+		return []string{word}
+	case word == strings.ToUpper(word):
+		return []string{word, lower, capitalize(lower)}
+	case word == capitalize(lower):
+		return []string{word, lower}
+	default:
+		return []string{word}
+	}
+}
+
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	if r == utf8.RuneError {
+		return word
+	}
+
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// matchCase writes suggestion in the case of the misspelled word.
+func matchCase(suggestion string, word string) string {
+	switch lower := strings.ToLower(word); {
+	case word == lower:
+		return suggestion
+	case word == strings.ToUpper(word) && utf8.RuneCountInString(word) > 1:
+		return strings.ToUpper(suggestion)
+	case word == capitalize(lower):
+		return capitalize(suggestion)
+	default:
+		return suggestion
+	}
+}
+
+var iso885915 = map[byte]rune{
+	0xA4: '€', 0xA6: 'Š', 0xA8: 'š', 0xB4: 'Ž',
+	0xB8: 'ž', 0xBC: 'Œ', 0xBD: 'œ', 0xBE: 'Ÿ',
+}
+
+func decode(data []byte, encoding string) (string, error) {
+	switch strings.ToUpper(strings.ReplaceAll(encoding, "-", "")) {
+	case "", "UTF8":
+		return strings.TrimPrefix(string(data), "\uFEFF"), nil
+
+	case "ISO88591", "ISO885915":
+		latin9 := strings.HasSuffix(encoding, "15")
+
+		var b strings.Builder
+
+		for _, c := range data {
+			if r, ok := iso885915[c]; ok && latin9 {
+				b.WriteRune(r)
+			} else {
+				b.WriteRune(rune(c))
+			}
+		}
+
+		return b.String(), nil
+
+	default:
+		return "", fmt.Errorf("unsupported dictionary encoding %q, convert it to UTF-8", encoding)
+	}
+}
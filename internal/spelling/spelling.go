@@ -0,0 +1,242 @@
+package spelling
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"x-dev/internal/store"
+	"x-dev/internal/twittertext"
+)
+
+const maxSuggestions = 3
+
+var (
+	wordPattern = regexp.MustCompile(`[\p{L}\p{M}]+(?:['’][\p{L}\p{M}]+)*`)
+
+	// skipPatterns match what is not prose: code spans and the mentions,
+	// hashtags and cashtags X links up.
+	skipPatterns = []*regexp.Regexp{
+		regexp.MustCompile("(?s)```.*?```"),
+		regexp.MustCompile("`[^`\n]+`"),
+		regexp.MustCompile(`[@＠][A-Za-z0-9_]+`),
+		regexp.MustCompile(`[#＃][\p{L}\p{M}\p{N}_]+`),
+		regexp.MustCompile(`\$[A-Za-z]{1,6}\b`),
+	}
+)
+
+// Misspelling is a word the dictionary does not know, with byte offsets
+// into the checked text.
+type Misspelling struct {
+	Word        string
+	Start       int
+	End         int
+	Suggestions []string
+}
+
+// Checker checks posts against a Hunspell dictionary and a personal word
+// list. A nil Checker finds nothing.
+type Checker struct {
+	dictionary *Dictionary
+	personal   map[string]bool
+}
+
+// New loads the dictionary for language, such as en_US, and adds words,
+// plus the words in wordsFile, one per line, to the personal word list.
+func New(language string, paths []string, words []string, wordsFile string) (*Checker, error) {
+	aff, dic, err := Find(language, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	dictionary, err := Load(aff, dic)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := &Checker{dictionary: dictionary, personal: map[string]bool{}}
+
+	for _, word := range words {
+		checker.personal[strings.TrimSpace(word)] = true
+	}
+
+	if wordsFile != "" {
+		if err := checker.loadWords(wordsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return checker, nil
+}
+
+func (c *Checker) loadWords(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read word list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" && !strings.HasPrefix(word, "#") {
+			c.personal[word] = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read word list: %w", err)
+	}
+
+	return nil
+}
+
+// Find returns the .aff and .dic files for language. It looks in paths
+// first, then in $DICPATH, the dictionaries folder of the x-yapper
+// directory and the places Hunspell dictionaries are usually installed.
+func Find(language string, paths []string) (string, string, error) {
+	names := []string{language}
+	if strings.Contains(language, "-") {
+		names = append(names, strings.ReplaceAll(language, "-", "_"))
+	}
+
+	searched := searchPaths(paths)
+
+	for _, dir := range searched {
+		for _, name := range names {
+			aff := filepath.Join(dir, name+".aff")
+			dic := filepath.Join(dir, name+".dic")
+
+			if fileExists(aff) && fileExists(dic) {
+				return aff, dic, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("no Hunspell dictionary for %q, looked in %s", language, strings.Join(searched, ", "))
+}
+
+func searchPaths(paths []string) []string {
+	searched := append([]string{}, paths...)
+
+	if dicPath := os.Getenv("DICPATH"); dicPath != "" {
+		searched = append(searched, filepath.SplitList(dicPath)...)
+	}
+
+	if dir, err := store.Dir(); err == nil {
+		searched = append(searched, filepath.Join(dir, "dictionaries"))
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		searched = append(searched,
+			filepath.Join(home, ".local", "share", "hunspell"),
+			filepath.Join(home, "Library", "Spelling"),
+		)
+	}
+
+	return append(searched,
+		"/usr/share/hunspell",
+		"/usr/share/myspell",
+		"/usr/share/myspell/dicts",
+		"/usr/local/share/hunspell",
+		"/opt/homebrew/share/hunspell",
+		"/Library/Spelling",
+	)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Check returns the words of text that are neither in the dictionary nor
+// in the personal word list. Links, mentions, hashtags, cashtags, code
+// spans, words in all caps and words joined to digits are skipped.
+func (c *Checker) Check(text string) []Misspelling {
+	if c == nil {
+		return nil
+	}
+
+	var misspellings []Misspelling
+
+	suggestions := map[string][]string{}
+
+	for _, loc := range wordPattern.FindAllStringIndex(blankSkipped(text), -1) {
+		word := text[loc[0]:loc[1]]
+
+		if isAcronym(word) || !standalone(text, loc[0], loc[1]) || c.known(word) {
+			continue
+		}
+
+		if _, ok := suggestions[word]; !ok {
+			suggestions[word] = c.dictionary.Suggest(word, maxSuggestions)
+		}
+
+		misspellings = append(misspellings, Misspelling{
+			Word:        word,
+			Start:       loc[0],
+			End:         loc[1],
+			Suggestions: suggestions[word],
+		})
+	}
+
+	return misspellings
+}
+
+func (c *Checker) known(word string) bool {
+	if c.personal[word] || c.personal[strings.ToLower(word)] {
+		return true
+	}
+
+	// Dictionaries spell contractions with a straight apostrophe, phones
+	// and editors often type a curly one.
+	return c.dictionary.Check(word) || c.dictionary.Check(strings.ReplaceAll(word, "’", "'"))
+}
+
+// blankSkipped replaces everything that is not prose with spaces, keeping
+// the offsets of the rest.
+func blankSkipped(text string) string {
+	blanked := []byte(text)
+
+	blank := func(start int, end int) {
+		for i := start; i < end; i++ {
+			blanked[i] = ' '
+		}
+	}
+
+	for _, span := range twittertext.ExtractURLs(text) {
+		blank(span.Start, span.End)
+	}
+
+	for _, pattern := range skipPatterns {
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			blank(loc[0], loc[1])
+		}
+	}
+
+	return string(blanked)
+}
+
+// standalone reports whether the word at start:end is not glued to digits
+// or underscores, as in identifiers and serial numbers.
+func standalone(text string, start int, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+
+	for _, r := range []rune{before, after} {
+		if unicode.IsDigit(r) || r == '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAcronym(word string) bool {
+	return utf8.RuneCountInString(word) > 1 && word == strings.ToUpper(word) && word != strings.ToLower(word)
+}
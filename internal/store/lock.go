@@ -0,0 +1,77 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lock is an advisory lock file that its holder keeps fresh with Touch. A
+// lock left untouched for longer than its stale period is assumed to belong
+// to a process that died and is taken over.
+type Lock struct {
+	path string
+}
+
+func AcquireLock(name string, staleAfter time.Duration) (*Lock, error) {
+	path, err := Path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_, writeErr := file.WriteString(strconv.Itoa(os.Getpid()))
+			closeErr := file.Close()
+
+			if err := errors.Join(writeErr, closeErr); err != nil {
+				return nil, fmt.Errorf("error writing %s: %w", name, err)
+			}
+
+			return &Lock{path: path}, nil
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("error creating %s: %w", name, err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if time.Since(info.ModTime()) < staleAfter {
+			holder, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("%s is held by process %s", name, strings.TrimSpace(string(holder)))
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("error removing stale %s: %w", name, err)
+		}
+	}
+
+	return nil, fmt.Errorf("could not acquire %s", name)
+}
+
+func (l *Lock) Touch() error {
+	now := time.Now()
+
+	if err := os.Chtimes(l.path, now, now); err != nil {
+		return fmt.Errorf("error refreshing lock: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error releasing lock: %w", err)
+	}
+
+	return nil
+}
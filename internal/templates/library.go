@@ -0,0 +1,152 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"x-dev/internal/store"
+)
+
+const (
+	libraryDir = "templates"
+	fileSuffix = ".tmpl"
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Path returns where the template called name is stored. Templates are
+// plain files in the templates directory, so they can also be managed with
+// any editor or checked into a repository.
+func Path(name string) (string, error) {
+	if !namePattern.MatchString(name) || strings.HasSuffix(name, fileSuffix) {
+		return "", fmt.Errorf("invalid template name %q, use letters, digits, '.', '-' and '_'", name)
+	}
+
+	return store.Path(filepath.Join(libraryDir, name+fileSuffix))
+}
+
+func List() ([]string, error) {
+	dir, err := store.Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, libraryDir))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("error reading templates: %w", err)
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), fileSuffix); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func Load(name string) (string, error) {
+	path, err := Path(name)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("no template named %q, create it with \"x-yapper template edit %s\"", name, name)
+		}
+
+		return "", fmt.Errorf("error reading template: %w", err)
+	}
+
+	return string(body), nil
+}
+
+func Save(name string, body string) error {
+	path, err := Path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		return fmt.Errorf("error saving template: %w", err)
+	}
+
+	return nil
+}
+
+// Variables lists the names a template reads with {{.name}}, other than
+// the built-in Time and Run, in order of first use.
+func Variables(body string) ([]string, error) {
+	tmpl, err := template.New("post").Funcs(funcs(Data{})).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var names []string
+	seen := map[string]bool{"Time": true, "Run": true}
+
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg)
+				}
+			}
+		case *parse.FieldNode:
+			if name := n.Ident[0]; !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	walk(tmpl.Tree.Root)
+
+	return names, nil
+}
@@ -0,0 +1,102 @@
+package templates
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Data is what a post template can refer to. Time is the moment the post
+// is for, in the time zone it was scheduled in; Run counts the posts sent
+// from the same recurring entry, starting at 1. Vars are available to the
+// template by name, as in {{.version}}.
+type Data struct {
+	Time time.Time
+	Run  int
+	Vars map[string]string
+}
+
+// Render executes body as a text/template. Besides the fields of Data,
+// templates can use:
+//
+//	date "Jan 2"            .Time in a Go time layout
+//	addDays 7               .Time moved by a number of days
+//	truncate 80 .notes      text cut to a number of characters
+//	url "https://x" .ver    a URL with escaped path segments appended
+//	query $url "k" "v"      a URL with query parameters added
+//	upper, lower, trim
+func Render(body string, data Data) (string, error) {
+	values := map[string]any{
+		"Time": data.Time,
+		"Run":  data.Run,
+	}
+
+	for name, value := range data.Vars {
+		if _, builtin := values[name]; builtin {
+			return "", fmt.Errorf("%s is built in and cannot be set as a variable", name)
+		}
+
+		values[name] = value
+	}
+
+	tmpl, err := template.New("post").Funcs(funcs(data)).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var out strings.Builder
+
+	if err := tmpl.Execute(&out, values); err != nil {
+		return "", fmt.Errorf("error rendering template: %w", err)
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+func funcs(data Data) template.FuncMap {
+	return template.FuncMap{
+		"date": func(layout string) string {
+			return data.Time.Format(layout)
+		},
+		"addDays": func(days int) time.Time {
+			return data.Time.AddDate(0, 0, days)
+		},
+		"truncate": truncate,
+		"url":      url.JoinPath,
+		"query":    addQuery,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+	}
+}
+
+func truncate(limit int, text string) string {
+	runes := []rune(text)
+	if limit <= 0 || len(runes) <= limit {
+		return text
+	}
+
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
+
+func addQuery(rawURL string, pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("query needs name and value pairs, got %d arguments", len(pairs))
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	q := u.Query()
+	for i := 0; i < len(pairs); i += 2 {
+		q.Set(pairs[i], pairs[i+1])
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
@@ -0,0 +1,188 @@
+package thread
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"x-dev/internal/twittertext"
+)
+
+const maxCounterPasses = 5
+
+var (
+	wordPattern       = regexp.MustCompile(`\S+\s*`)
+	sentenceEndSuffix = regexp.MustCompile(`[.!?…]+["'”’)\]]*$`)
+)
+
+// AutoSplit breaks text that is too long for one post into thread-sized
+// chunks. It prefers sentence boundaries, falls back to word boundaries and
+// never breaks inside a URL or @mention. When numbered is set each chunk
+// gets a " i/n" counter and the room for it is reserved in the budget.
+func AutoSplit(text string, limit int, numbered bool) ([]string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+
+	if !numbered {
+		return pack(text, limit)
+	}
+
+	total := 1
+
+	for range maxCounterPasses {
+		chunks, err := pack(text, limit-len(counter(total, total)))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(chunks) == total {
+			for i := range chunks {
+				chunks[i] += counter(i+1, total)
+			}
+
+			return chunks, nil
+		}
+
+		total = len(chunks)
+	}
+
+	return nil, fmt.Errorf("could not settle on a thread length for %d characters", twittertext.WeightedLength(text))
+}
+
+func counter(index int, total int) string {
+	return fmt.Sprintf(" %d/%d", index, total)
+}
+
+func pack(text string, budget int) ([]string, error) {
+	if budget <= 0 {
+		return nil, fmt.Errorf("post length limit of %d is too small to split into", budget)
+	}
+
+	var chunks []string
+	var current strings.Builder
+
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+
+		current.Reset()
+	}
+
+	fits := func(extra string) bool {
+		return twittertext.WeightedLength(strings.TrimSpace(current.String()+extra)) <= budget
+	}
+
+	for _, sentence := range sentences(text) {
+		if fits(sentence) {
+			current.WriteString(sentence)
+			continue
+		}
+
+		flush()
+
+		if fits(sentence) {
+			current.WriteString(sentence)
+			continue
+		}
+
+		for _, word := range wordPattern.FindAllString(sentence, -1) {
+			if fits(word) {
+				current.WriteString(word)
+				continue
+			}
+
+			flush()
+
+			if fits(word) {
+				current.WriteString(word)
+				continue
+			}
+
+			pieces, err := breakWord(word, budget)
+			if err != nil {
+				return nil, err
+			}
+
+			chunks = append(chunks, pieces[:len(pieces)-1]...)
+			current.WriteString(pieces[len(pieces)-1])
+		}
+	}
+
+	flush()
+
+	return chunks, nil
+}
+
+// sentences groups the words of text into sentences, keeping the original
+// whitespace so line breaks survive the split.
+func sentences(text string) []string {
+	var result []string
+	var current strings.Builder
+
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		current.WriteString(word)
+
+		if sentenceEndSuffix.MatchString(strings.TrimSpace(word)) || strings.Contains(word, "\n") {
+			result = append(result, current.String())
+			current.Reset()
+		}
+	}
+
+	if current.Len() > 0 {
+		result = append(result, current.String())
+	}
+
+	return result
+}
+
+// breakWord cuts a word longer than budget into pieces that fit, never
+// inside a link or @mention, using the same spans the link rules and the
+// mention check see.
+func breakWord(word string, budget int) ([]string, error) {
+	token := strings.TrimSpace(word)
+	atomic := append(twittertext.ExtractURLs(token), twittertext.ExtractMentions(token)...)
+
+	var pieces []string
+
+	for start := 0; start < len(token); {
+		end := len(token)
+		for end > start && (twittertext.WeightedLength(token[start:end]) > budget || inside(atomic, end)) {
+			_, size := utf8.DecodeLastRuneInString(token[start:end])
+			end -= size
+		}
+
+		if end == start {
+			for _, span := range atomic {
+				if start >= span.Start && start < span.End {
+					return nil, fmt.Errorf("%q is longer than the post limit and cannot be split", token[span.Start:span.End])
+				}
+			}
+
+			_, size := utf8.DecodeRuneInString(token[start:])
+			end = start + size
+		}
+
+		pieces = append(pieces, token[start:end])
+		start = end
+	}
+
+	pieces[len(pieces)-1] += word[len(token):]
+
+	return pieces, nil
+}
+
+// inside reports whether offset falls strictly within one of spans, so a
+// cut there would break it.
+func inside(spans []twittertext.Span, offset int) bool {
+	for _, span := range spans {
+		if offset > span.Start && offset < span.End {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package thread
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"x-dev/internal/twittertext"
+)
+
+func TestAutoSplit(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		numbered bool
+		want     []string
+	}{
+		{"empty", "  ", 20, false, nil},
+		{"fits", "Short post.", 20, false, []string{"Short post."}},
+		{"sentences", "One two three. Four five six. Seven.", 20, false,
+			[]string{"One two three.", "Four five six.", "Seven."}},
+		{"sentences packed together", "Hi. Yes. No. Maybe so.", 12, false,
+			[]string{"Hi. Yes. No.", "Maybe so."}},
+		{"words when a sentence is too long", "alpha beta gamma delta epsilon", 12, false,
+			[]string{"alpha beta", "gamma delta", "epsilon"}},
+		{"line breaks end a sentence", "first line\nsecond line", 15, false,
+			[]string{"first line", "second line"}},
+		{"a long word is cut", "abcdefghijklmnopqrstuvwxyz", 10, false,
+			[]string{"abcdefghij", "klmnopqrst", "uvwxyz"}},
+		{"numbered", "One two three. Four five six. Seven.", 20, true,
+			[]string{"One two three. 1/3", "Four five six. 2/3", "Seven. 3/3"}},
+		// The counter takes room in every post.
+		{"numbered needs another post", "aaaa bbbb cccc dddd eeee", 10, true,
+			[]string{"aaaa 1/5", "bbbb 2/5", "cccc 3/5", "dddd 4/5", "eeee 5/5"}},
+		// A link counts as 23 characters and stays whole.
+		{"links", "Read https://example.com/a/very/long/path/that/goes/on now", 30, false,
+			[]string{"Read https://example.com/a/very/long/path/that/goes/on", "now"}},
+		{"mentions", "thanks @someone_long for it", 15, false,
+			[]string{"thanks", "@someone_long", "for it"}},
+		// Links after punctuation and bare domains are not cut either.
+		{"bracketed link", strings.Repeat("x", 20) + "(https://example.com/path)", 25, false,
+			[]string{strings.Repeat("x", 20) + "(", "https://example.com/path)"}},
+		{"bare domain", strings.Repeat("x", 20) + ":example.com/path", 25, false,
+			[]string{strings.Repeat("x", 20) + ":", "example.com/path"}},
+		{"CJK counts double", strings.Repeat("あ", 12), 10, false,
+			[]string{strings.Repeat("あ", 5), strings.Repeat("あ", 5), strings.Repeat("あ", 2)}},
+	}
+
+	for _, test := range tests {
+		got, err := AutoSplit(test.text, test.limit, test.numbered)
+		if err != nil {
+			t.Errorf("%s: AutoSplit failed: %v", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: AutoSplit = %q, want %q", test.name, got, test.want)
+		}
+
+		for _, chunk := range got {
+			if length := twittertext.WeightedLength(chunk); length > test.limit {
+				t.Errorf("%s: chunk %q is %d long, over %d", test.name, chunk, length, test.limit)
+			}
+		}
+	}
+}
+
+func TestAutoSplitRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		numbered bool
+	}{
+		{"link longer than the limit", "see https://example.com/page", 20, false},
+		{"bare domain longer than the limit", "see example.com/a/long/path", 20, false},
+		{"bracketed link longer than the limit", "(https://example.com/page)", 20, false},
+		{"limit too small for the counter", "some words here", 3, true},
+	}
+
+	for _, test := range tests {
+		if got, err := AutoSplit(test.text, test.limit, test.numbered); err == nil {
+			t.Errorf("%s: AutoSplit = %q, want an error", test.name, got)
+		}
+	}
+}
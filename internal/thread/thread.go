@@ -0,0 +1,147 @@
+package thread
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"x-dev/internal/models"
+	"x-dev/internal/store"
+)
+
+const pendingFile = "threads.json"
+
+var pendingMu sync.Mutex
+
+// Split breaks an editor buffer into posts on lines that consist solely of
+// the separator. Empty segments are dropped.
+func Split(content string, separator string) []string {
+	var posts []string
+	var current []string
+
+	flush := func() {
+		post := strings.TrimSpace(strings.Join(current, "\n"))
+		if post != "" {
+			posts = append(posts, post)
+		}
+
+		current = current[:0]
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.TrimSpace(line) == separator {
+			flush()
+			continue
+		}
+
+		current = append(current, line)
+	}
+
+	flush()
+
+	return posts
+}
+
+func New(posts []models.ThreadPost) *models.Thread {
+	now := time.Now()
+
+	return &models.Thread{
+		ID:        now.Format("20060102-150405.000"),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Posts:     posts,
+	}
+}
+
+func SentCount(thread *models.Thread) int {
+	sent := 0
+
+	for _, post := range thread.Posts {
+		if post.PostID != "" {
+			sent++
+		}
+	}
+
+	return sent
+}
+
+func LoadPending() ([]*models.Thread, error) {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	threads, err := loadPending()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(threads, func(i, j int) bool {
+		return threads[i].UpdatedAt.After(threads[j].UpdatedAt)
+	})
+
+	return threads, nil
+}
+
+func SavePending(thread *models.Thread) error {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	threads, err := loadPending()
+	if err != nil {
+		return err
+	}
+
+	thread.UpdatedAt = time.Now()
+
+	replaced := false
+
+	for i := range threads {
+		if threads[i].ID == thread.ID {
+			threads[i] = thread
+			replaced = true
+		}
+	}
+
+	if !replaced {
+		threads = append(threads, thread)
+	}
+
+	return store.Save(pendingFile, threads)
+}
+
+func RemovePending(id string) error {
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+
+	threads, err := loadPending()
+	if err != nil {
+		return err
+	}
+
+	kept := threads[:0]
+
+	for _, thread := range threads {
+		if thread.ID != id {
+			kept = append(kept, thread)
+		}
+	}
+
+	return store.Save(pendingFile, kept)
+}
+
+func loadPending() ([]*models.Thread, error) {
+	var threads []*models.Thread
+
+	if _, err := store.Load(pendingFile, &threads); err != nil {
+		return nil, fmt.Errorf("failed to load unfinished threads: %w", err)
+	}
+
+	return threads, nil
+}
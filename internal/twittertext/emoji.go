@@ -0,0 +1,144 @@
+package twittertext
+
+const (
+	zeroWidthJoiner  = 0x200D
+	variationText    = 0xFE0E
+	variationEmoji   = 0xFE0F
+	combiningKeycap  = 0x20E3
+	tagCancel        = 0xE007F
+	regionalFirst    = 0x1F1E6
+	regionalLast     = 0x1F1FF
+	skinToneFirst    = 0x1F3FB
+	skinToneLast     = 0x1F3FF
+	tagFirst         = 0xE0020
+	tagLast          = 0xE007E
+	keycapCharacters = "0123456789#*"
+)
+
+// emojiRanges covers code points that render as emoji by default.
+var emojiRanges = [][2]rune{
+	{0x231A, 0x231B}, {0x23E9, 0x23F3}, {0x23F8, 0x23FA}, {0x25FD, 0x25FE},
+	{0x2600, 0x27BF}, {0x2934, 0x2935}, {0x2B05, 0x2B07}, {0x2B1B, 0x2B1C},
+	{0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x3030, 0x3030}, {0x303D, 0x303D},
+	{0x3297, 0x3297}, {0x3299, 0x3299}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F170, 0x1F171}, {0x1F17E, 0x1F17F}, {0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A},
+	{0x1F201, 0x1F202}, {0x1F21A, 0x1F21A}, {0x1F22F, 0x1F22F}, {0x1F232, 0x1F23A},
+	{0x1F250, 0x1F251}, {0x1F300, 0x1F6FF}, {0x1F7E0, 0x1F7F0}, {0x1F90C, 0x1F9FF},
+	{0x1FA70, 0x1FAFF},
+}
+
+// textDefaultEmoji only turn into emoji when followed by U+FE0F.
+var textDefaultEmoji = [][2]rune{
+	{0x00A9, 0x00A9}, {0x00AE, 0x00AE}, {0x203C, 0x203C}, {0x2049, 0x2049},
+	{0x2122, 0x2122}, {0x2139, 0x2139}, {0x2194, 0x2199}, {0x21A9, 0x21AA},
+	{0x2328, 0x2328}, {0x23CF, 0x23CF}, {0x24C2, 0x24C2}, {0x25AA, 0x25AB},
+	{0x25B6, 0x25B6}, {0x25C0, 0x25C0}, {0x25FB, 0x25FC},
+}
+
+// emojiSequenceLength returns how many runes starting at index form a single
+// emoji, including flags, keycaps, skin tones, tag sequences and ZWJ
+// sequences. It returns 0 when no emoji starts there.
+func emojiSequenceLength(runes []rune, index int) int {
+	at := func(i int) rune {
+		if i < len(runes) {
+			return runes[i]
+		}
+
+		return -1
+	}
+
+	first := runes[index]
+
+	if isRegionalIndicator(first) {
+		if isRegionalIndicator(at(index + 1)) {
+			return 2
+		}
+
+		return 0
+	}
+
+	i := index
+
+	switch {
+	case containsRune(keycapCharacters, first):
+		next := index + 1
+		if at(next) == variationEmoji {
+			next++
+		}
+
+		if at(next) == combiningKeycap {
+			return next + 1 - index
+		}
+
+		return 0
+
+	case inRanges(first, textDefaultEmoji):
+		if at(index+1) != variationEmoji {
+			return 0
+		}
+
+	case !inRanges(first, emojiRanges):
+		return 0
+	}
+
+	for {
+		i = emojiElementEnd(i, at)
+
+		if at(i) != zeroWidthJoiner || !isEmojiBase(at(i+1)) {
+			return i - index
+		}
+
+		i++
+	}
+}
+
+func emojiElementEnd(i int, at func(int) rune) int {
+	i++
+
+	switch r := at(i); {
+	case r == variationEmoji || r == variationText:
+		i++
+	case r >= skinToneFirst && r <= skinToneLast:
+		i++
+	}
+
+	if at(i) >= tagFirst && at(i) <= tagLast {
+		for at(i) >= tagFirst && at(i) <= tagLast {
+			i++
+		}
+
+		if at(i) == tagCancel {
+			i++
+		}
+	}
+
+	return i
+}
+
+func isEmojiBase(r rune) bool {
+	return inRanges(r, emojiRanges) || inRanges(r, textDefaultEmoji)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalFirst && r <= regionalLast
+}
+
+func inRanges(r rune, ranges [][2]rune) bool {
+	for _, bounds := range ranges {
+		if r >= bounds[0] && r <= bounds[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsRune(set string, r rune) bool {
+	for _, candidate := range set {
+		if candidate == r {
+			return true
+		}
+	}
+
+	return false
+}
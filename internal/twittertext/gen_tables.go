@@ -0,0 +1,228 @@
+//go:build ignore
+
+// gen_tables writes tables.go from the Unicode character database:
+//
+//	go run gen_tables.go [-ucd URL or directory] [-version 14.0.0]
+//
+// It reads UnicodeData.txt and CompositionExclusions.txt, from unicode.org
+// unless -ucd names a directory holding them.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type composition struct {
+	first     rune
+	second    rune
+	composite rune
+}
+
+type classRange struct {
+	first rune
+	last  rune
+	class uint64
+}
+
+func main() {
+	version := flag.String("version", "14.0.0", "Unicode version the tables are generated from")
+	ucd := flag.String("ucd", "", "URL or directory of the character database (default unicode.org for -version)")
+	output := flag.String("o", "tables.go", "file to write")
+	flag.Parse()
+
+	if *ucd == "" {
+		*ucd = "https://www.unicode.org/Public/" + *version + "/ucd"
+	}
+
+	unicodeData, err := read(*ucd, "UnicodeData.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	exclusionData, err := read(*ucd, "CompositionExclusions.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	excluded, err := parseExclusions(exclusionData)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	compositions, classes, err := parseUnicodeData(unicodeData, excluded)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by gen_tables.go from the Unicode %s character database. DO NOT EDIT.\n\n", *version)
+	fmt.Fprintln(&buf, "package twittertext")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "// compositions maps a canonical pair to its primary composite, excluding")
+	fmt.Fprintln(&buf, "// composition exclusions. Hangul syllables are composed algorithmically.")
+	fmt.Fprintln(&buf, "var compositions = map[[2]rune]rune{")
+
+	for _, c := range compositions {
+		fmt.Fprintf(&buf, "{0x%04X, 0x%04X}: 0x%04X,\n", c.first, c.second, c.composite)
+	}
+
+	fmt.Fprintln(&buf, "}")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "// combiningClasses lists the non-zero canonical combining classes as")
+	fmt.Fprintln(&buf, "// inclusive code point ranges.")
+	fmt.Fprintln(&buf, "var combiningClasses = []combiningRange{")
+
+	for _, r := range classes {
+		fmt.Fprintf(&buf, "{0x%04X, 0x%04X, %d},\n", r.first, r.last, r.class)
+	}
+
+	fmt.Fprintln(&buf, "}")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*output, source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func read(ucd string, name string) ([]byte, error) {
+	if !strings.HasPrefix(ucd, "http://") && !strings.HasPrefix(ucd, "https://") {
+		return os.ReadFile(filepath.Join(ucd, name))
+	}
+
+	resp, err := http.Get(ucd + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", name, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// parseExclusions returns the code points listed in
+// CompositionExclusions.txt. Singletons and non-starter decompositions are
+// only mentioned in its comments; parseUnicodeData leaves those out itself.
+func parseExclusions(data []byte) (map[rune]bool, error) {
+	excluded := map[rune]bool{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+
+		r, err := parseRune(line)
+		if err != nil {
+			return nil, fmt.Errorf("CompositionExclusions.txt: %w", err)
+		}
+
+		excluded[r] = true
+	}
+
+	return excluded, scanner.Err()
+}
+
+// parseUnicodeData collects the primary composites, in code point order,
+// and the non-zero combining classes merged into ranges.
+func parseUnicodeData(data []byte, excluded map[rune]bool) ([]composition, []classRange, error) {
+	type decomposition struct {
+		r     rune
+		parts []rune
+	}
+
+	classOf := map[rune]uint64{}
+
+	var decompositions []decomposition
+	var classes []classRange
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ";")
+		if len(fields) < 6 {
+			continue
+		}
+
+		r, err := parseRune(fields[0])
+		if err != nil {
+			return nil, nil, fmt.Errorf("UnicodeData.txt: %w", err)
+		}
+
+		class, err := strconv.ParseUint(fields[3], 10, 8)
+		if err != nil {
+			return nil, nil, fmt.Errorf("UnicodeData.txt: %s: %w", fields[0], err)
+		}
+
+		if class != 0 {
+			classOf[r] = class
+
+			if n := len(classes); n > 0 && classes[n-1].last == r-1 && classes[n-1].class == class {
+				classes[n-1].last = r
+			} else {
+				classes = append(classes, classRange{first: r, last: r, class: class})
+			}
+		}
+
+		// Compatibility decompositions start with a <tag>.
+		if fields[5] == "" || strings.HasPrefix(fields[5], "<") {
+			continue
+		}
+
+		var parts []rune
+
+		for _, field := range strings.Fields(fields[5]) {
+			part, err := parseRune(field)
+			if err != nil {
+				return nil, nil, fmt.Errorf("UnicodeData.txt: %s: %w", fields[0], err)
+			}
+
+			parts = append(parts, part)
+		}
+
+		decompositions = append(decompositions, decomposition{r: r, parts: parts})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var compositions []composition
+
+	for _, d := range decompositions {
+		if len(d.parts) != 2 || excluded[d.r] || classOf[d.parts[0]] != 0 {
+			continue
+		}
+
+		compositions = append(compositions, composition{first: d.parts[0], second: d.parts[1], composite: d.r})
+	}
+
+	return compositions, classes, nil
+}
+
+func parseRune(field string) (rune, error) {
+	value, err := strconv.ParseUint(strings.TrimSpace(field), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid code point %q", field)
+	}
+
+	return rune(value), nil
+}
@@ -0,0 +1,113 @@
+package twittertext
+
+//go:generate go run gen_tables.go
+
+import "sort"
+
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+type combiningRange struct {
+	first rune
+	last  rune
+	class uint8
+}
+
+// NFC applies canonical ordering and canonical composition to text. Input
+// that is already composed, which is almost all real input, is returned
+// unchanged.
+func NFC(text string) string {
+	runes := []rune(text)
+
+	reorder(runes)
+
+	result := make([]rune, 0, len(runes))
+	starter := -1
+	lastClass := uint8(0)
+
+	for _, r := range runes {
+		class := combiningClass(r)
+
+		if starter >= 0 {
+			adjacent := starter == len(result)-1
+			unblocked := adjacent || (lastClass != 0 && lastClass < class)
+
+			if unblocked {
+				if composite, ok := compose(result[starter], r); ok {
+					result[starter] = composite
+					continue
+				}
+			}
+		}
+
+		if class == 0 {
+			starter = len(result)
+			lastClass = 0
+		} else {
+			lastClass = class
+		}
+
+		result = append(result, r)
+	}
+
+	return string(result)
+}
+
+func reorder(runes []rune) {
+	for start := 0; start < len(runes); start++ {
+		if combiningClass(runes[start]) == 0 {
+			continue
+		}
+
+		end := start
+		for end < len(runes) && combiningClass(runes[end]) != 0 {
+			end++
+		}
+
+		if end-start > 1 {
+			run := runes[start:end]
+			sort.SliceStable(run, func(i, j int) bool {
+				return combiningClass(run[i]) < combiningClass(run[j])
+			})
+		}
+
+		start = end
+	}
+}
+
+func compose(first rune, second rune) (rune, bool) {
+	if first >= hangulLBase && first < hangulLBase+hangulLCount &&
+		second >= hangulVBase && second < hangulVBase+hangulVCount {
+		return hangulSBase + ((first-hangulLBase)*hangulVCount+(second-hangulVBase))*hangulTCount, true
+	}
+
+	if first >= hangulSBase && first < hangulSBase+hangulSCount && (first-hangulSBase)%hangulTCount == 0 &&
+		second > hangulTBase && second < hangulTBase+hangulTCount {
+		return first + (second - hangulTBase), true
+	}
+
+	composite, ok := compositions[[2]rune{first, second}]
+
+	return composite, ok
+}
+
+func combiningClass(r rune) uint8 {
+	index := sort.Search(len(combiningClasses), func(i int) bool {
+		return combiningClasses[i].last >= r
+	})
+
+	if index < len(combiningClasses) && combiningClasses[index].first <= r {
+		return combiningClasses[index].class
+	}
+
+	return 0
+}
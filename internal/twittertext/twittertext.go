@@ -0,0 +1,210 @@
+package twittertext
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Config mirrors the twitter-text v3 configuration. Weights are expressed
+// in units of 1/Scale of a character.
+type Config struct {
+	Scale                int
+	DefaultWeight        int
+	TransformedURLLength int
+	EmojiWeight          int
+	Ranges               []WeightRange
+}
+
+type WeightRange struct {
+	Start  rune
+	End    rune
+	Weight int
+}
+
+type Span struct {
+	Start int
+	End   int
+	Text  string
+}
+
+var V3 = Config{
+	Scale:                100,
+	DefaultWeight:        200,
+	TransformedURLLength: 23,
+	EmojiWeight:          200,
+	Ranges: []WeightRange{
+		{Start: 0, End: 4351, Weight: 100},
+		{Start: 8192, End: 8205, Weight: 100},
+		{Start: 8208, End: 8223, Weight: 100},
+		{Start: 8242, End: 8247, Weight: 100},
+	},
+}
+
+const urlTrailingPunctuation = ".,:;!?'\")]}"
+
+var (
+	protocolURLPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"]+`)
+	bareURLPattern     = regexp.MustCompile(`(?i)(?:^|[^\w@.$/-])((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+` +
+		`(?:com|org|net|edu|gov|io|dev|co|app|ai|me|ly|gg|xyz|info|biz|tv|so|sh|us|uk|de|fr|jp|ca|au|in|eu|nl|es|it|se|ch|be)` +
+		`(?:/[^\s<>"]*)?)(?:$|[^\w-])`)
+
+	mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_!#$%&*@＠])([@＠][A-Za-z0-9_]{1,15})`)
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])([#＃][\p{L}\p{M}\p{N}_]*[\p{L}\p{M}][\p{L}\p{M}\p{N}_]*)`)
+)
+
+func WeightedLength(text string) int {
+	return V3.WeightedLength(text)
+}
+
+// WeightedLength counts text the way X does when enforcing the post length
+// limit: NFC normalized, every URL as TransformedURLLength characters, each
+// emoji sequence as EmojiWeight and other code points by range.
+func (c Config) WeightedLength(text string) int {
+	text = NFC(text)
+
+	weighted := 0
+	offset := 0
+
+	for _, url := range ExtractURLs(text) {
+		weighted += c.weighText(text[offset:url.Start])
+		weighted += c.TransformedURLLength * c.Scale
+		offset = url.End
+	}
+
+	weighted += c.weighText(text[offset:])
+
+	return weighted / c.Scale
+}
+
+func (c Config) weighText(text string) int {
+	runes := []rune(text)
+	weighted := 0
+
+	for i := 0; i < len(runes); {
+		if length := emojiSequenceLength(runes, i); length > 0 {
+			weighted += c.EmojiWeight
+			i += length
+
+			continue
+		}
+
+		weighted += c.weight(runes[i])
+		i++
+	}
+
+	return weighted
+}
+
+func (c Config) weight(r rune) int {
+	for _, weightRange := range c.Ranges {
+		if r >= weightRange.Start && r <= weightRange.End {
+			return weightRange.Weight
+		}
+	}
+
+	return c.DefaultWeight
+}
+
+// ExtractURLs finds URLs with a protocol as well as bare domains on common
+// top-level domains, returning byte offsets into text.
+func ExtractURLs(text string) []Span {
+	var spans []Span
+
+	covered := func(start int, end int) bool {
+		for _, span := range spans {
+			if start < span.End && end > span.Start {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, match := range protocolURLPattern.FindAllStringIndex(text, -1) {
+		end := match[0] + len(strings.TrimRight(text[match[0]:match[1]], urlTrailingPunctuation))
+		spans = append(spans, Span{Start: match[0], End: end, Text: text[match[0]:end]})
+	}
+
+	for _, match := range bareURLPattern.FindAllStringSubmatchIndex(text, -1) {
+		start := match[2]
+		end := start + len(strings.TrimRight(text[start:match[3]], urlTrailingPunctuation))
+
+		if !covered(start, end) {
+			spans = append(spans, Span{Start: start, End: end, Text: text[start:end]})
+		}
+	}
+
+	sortSpans(spans)
+
+	return spans
+}
+
+// ExtractMentions finds @mentions outside URLs. Span.Text is the username
+// without the @.
+func ExtractMentions(text string) []Span {
+	var spans []Span
+
+	urls := ExtractURLs(text)
+
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+
+		// A longer name, an email address or a URL scheme is not a mention.
+		rest := text[end:]
+		if rest != "" && (isUsernameByte(rest[0]) || rest[0] == '@' || strings.HasPrefix(rest, "＠") || strings.HasPrefix(rest, "://")) {
+			continue
+		}
+
+		if inSpans(urls, start) {
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		spans = append(spans, Span{Start: start, End: end, Text: text[start+size : end]})
+	}
+
+	return spans
+}
+
+// ExtractHashtags finds #hashtags outside URLs. Span.Text is the tag
+// without the #.
+func ExtractHashtags(text string) []Span {
+	var spans []Span
+
+	urls := ExtractURLs(text)
+
+	for _, match := range hashtagPattern.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[2], match[3]
+		if inSpans(urls, start) {
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		spans = append(spans, Span{Start: start, End: end, Text: text[start+size : end]})
+	}
+
+	return spans
+}
+
+func isUsernameByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func inSpans(spans []Span, offset int) bool {
+	for _, span := range spans {
+		if offset >= span.Start && offset < span.End {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortSpans(spans []Span) {
+	for i := 1; i < len(spans); i++ {
+		for j := i; j > 0 && spans[j].Start < spans[j-1].Start; j-- {
+			spans[j], spans[j-1] = spans[j-1], spans[j]
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package twittertext
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestWeightedLength uses the weighted length cases of twitter-text's
+// conformance suite (conformance/validate.yml, v3 with discounted emoji).
+func TestWeightedLength(t *testing.T) {
+	tests := []struct {
+		description string
+		text        string
+		want        int
+	}{
+		{"Regular Tweet", "This is a test.", 15},
+		{"Regular Tweet with url", "Hi http://test.co", 26},
+		{"Tweet with a long url", "Hi https://www.example.com/a/path/that/is/much/longer/than/twenty/three?characters=true", 26},
+		{"Bare domain", "Hi example.com/path", 26},
+		{"Just an emoji", "😷", 2},
+		{"Count unicode emoji chars inside the basic multilingual plane", "😷👾😡🔥💩", 10},
+		{"Count unicode emoji chars outside the basic multilingual plane with skin tone modifiers", "🙋🏽👨‍🎤", 4},
+		{"Count a mix of single byte single word, and double word unicode characters", "H🐱☺👨‍👩‍👧‍👦", 7},
+		{"Count a flag as one emoji", "🇯🇵", 2},
+		{"Count a keycap as one emoji", "1️⃣", 2},
+		{"Count a tag sequence as one emoji", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", 2},
+		{"Allow 140 CJK chars", strings.Repeat("あ", 140), 280},
+		{"Disallow 141 CJK chars", strings.Repeat("あ", 141), 282},
+		{"Allow 280 Latin chars", strings.Repeat("a", 280), 280},
+		{"Count Latin-1 and Cyrillic as one", "café привет", 11},
+		{"Count dashes and quotes as one, but not the ellipsis", "a\u2013b \u2014 \u201cc\u201d \u2026", 12},
+		{"Count a decomposed character after NFC", "cafe\u0301", 4},
+		{"Count a decomposed Hangul syllable after NFC", "\u1100\u1161", 2},
+	}
+
+	for _, test := range tests {
+		if got := WeightedLength(test.text); got != test.want {
+			t.Errorf("%s: WeightedLength(%q) = %d, want %d", test.description, test.text, got, test.want)
+		}
+	}
+}
+
+func TestNFC(t *testing.T) {
+	tests := map[string]string{
+		"cafe\u0301":                 "caf\u00e9",
+		"A\u030a":                    "\u00c5",
+		"a\u0323\u0302":              "\u1ead",
+		"a\u0302\u0323":              "\u1ead",
+		"\u1100\u1161\u11a8":         "\uac01",
+		"already composed caf\u00e9": "already composed caf\u00e9",
+	}
+
+	for input, want := range tests {
+		if got := NFC(input); got != want {
+			t.Errorf("NFC(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestExtractURLs(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"see https://example.com/a.", []string{"https://example.com/a"}},
+		{"(https://example.com/a)", []string{"https://example.com/a"}},
+		{"bare example.com/path and www.example.org", []string{"example.com/path", "www.example.org"}},
+		{"mail bob@example.com", nil},
+		{"no links here", nil},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, span := range ExtractURLs(test.text) {
+			got = append(got, test.text[span.Start:span.End])
+		}
+
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("ExtractURLs(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
@@ -64,6 +64,25 @@ func ExchangeCodeForToken(ctx context.Context, clientID, clientSecret, codeVerif
 	data.Set("code_verifier", codeVerifier)
 	data.Set("redirect_uri", fmt.Sprintf("http://localhost:%s%s", callbackPort, callbackEndpoint))
 
+	return requestToken(ctx, data)
+}
+
+// RefreshToken trades a refresh token for a new access token. X rotates
+// refresh tokens, so the returned refresh token replaces the old one.
+func RefreshToken(ctx context.Context, clientID, clientSecret, refreshToken string) (*models.TokenResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	data := url.Values{}
+	data.Set("client_id", clientID)
+	data.Set("client_secret", clientSecret)
+	data.Set("refresh_token", refreshToken)
+	data.Set("grant_type", "refresh_token")
+
+	return requestToken(ctx, data)
+}
+
+func requestToken(ctx context.Context, data url.Values) (*models.TokenResponse, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tknEndpoint, strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("error creating token request: %w", err)